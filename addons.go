@@ -1,6 +1,9 @@
 package main
 
 import (
+	_ "github.com/yittg/ving/addons/compare"
+	_ "github.com/yittg/ving/addons/detail"
+	_ "github.com/yittg/ving/addons/eventlog"
 	_ "github.com/yittg/ving/addons/help"
 	_ "github.com/yittg/ving/addons/port"
 	_ "github.com/yittg/ving/addons/trace"
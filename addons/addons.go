@@ -1,6 +1,9 @@
 package addons
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // AddOn extend this utility with some useful features
 // all add-ons should implements this interface
@@ -17,3 +20,15 @@ type AddOn interface {
 
 	GetUI() UI
 }
+
+// Headless add-on can work in non-interactive mode
+type Headless interface {
+	// Involved represents whether the add-on has work to do
+	Involved() bool
+
+	// Finished represents whether the work is finished
+	Finished() bool
+
+	// Report the result, returns false if the result is not satisfied
+	Report(w io.Writer) bool
+}
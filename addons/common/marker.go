@@ -0,0 +1,19 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/yittg/ving/event"
+)
+
+// MarkerLabel represents the latest marker as a border label, empty if none
+func MarkerLabel(events *event.Log) string {
+	if events == nil {
+		return ""
+	}
+	m := events.LatestMarker()
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf(" ┊ %s ", m.Label())
+}
@@ -75,6 +75,20 @@ func (tl *TargetList) callBackSelected() {
 	tl.selectedCb(sid)
 }
 
+// Select the target `id` if it's in list
+func (tl *TargetList) Select(id int) {
+	for idx, sid := range tl.idxMap {
+		if sid != id {
+			continue
+		}
+		tl.selectID = idx
+		if tl.opt.SelectOnMove {
+			tl.callBackSelected()
+		}
+		return
+	}
+}
+
 // OnEnter see `ConfirmAware`
 func (tl *TargetList) OnEnter() {
 	if tl.selectID < 0 {
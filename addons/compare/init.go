@@ -0,0 +1,7 @@
+package compare
+
+import "github.com/yittg/ving/addons"
+
+func init() {
+	addons.Register(newCompare())
+}
@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yittg/ving/addons"
+	"github.com/yittg/ving/event"
+	"github.com/yittg/ving/statistic"
+)
+
+type runtime struct {
+	targets   *addons.Targets
+	statistic func(int) *statistic.Detail
+	events    *event.Log
+
+	ui         *ui
+	initUILock sync.Once
+}
+
+func newCompare() addons.AddOn {
+	return &runtime{}
+}
+
+// Desc of this compare add-on
+func (*runtime) Desc() string {
+	return "compare two targets, or a target before and after a marker"
+}
+
+// Init see `AddOn.Init`
+func (rt *runtime) Init(envoy *addons.Envoy) {
+	rt.statistic = envoy.Statistic
+	rt.targets = envoy.Targets
+	rt.events = envoy.Events
+}
+
+// Start see `AddOn.Start`
+func (rt *runtime) Start(context.Context) {
+}
+
+// Schedule see `AddOn.Schedule`
+func (rt *runtime) Schedule() {
+}
+
+// State see `AddOn.State`, nothing to provide, the statistic is looked up by target
+func (rt *runtime) State() interface{} {
+	return nil
+}
+
+// GetUI see `AddOn.GetUI`
+func (rt *runtime) GetUI() addons.UI {
+	if rt.ui == nil {
+		rt.initUILock.Do(func() {
+			rt.ui = newUI(rt)
+		})
+	}
+	return rt.ui
+}
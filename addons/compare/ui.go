@@ -0,0 +1,291 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui"
+	"github.com/yittg/ving/addons/common"
+	"github.com/yittg/ving/event"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/types"
+)
+
+const (
+	compareHeight = 14
+	refreshPeriod = time.Second
+	columnWidth   = 22
+	labelWidth    = 9
+	maxBarWidth   = 12
+)
+
+type ui struct {
+	*common.TargetList
+
+	par *termui.Par
+
+	// targets compared, -1 if not picked
+	a, b int
+	// marker of the session splits records of target `a` into before and after, nil if comparing two targets
+	marker *event.Event
+	// hint shown instead of the comparison, until the next key
+	hint string
+
+	refreshAt time.Time
+
+	source *runtime
+}
+
+func newUI(rt *runtime) *ui {
+	return &ui{
+		a:      -1,
+		b:      -1,
+		source: rt,
+	}
+}
+
+// Init see `UI`
+func (u *ui) Init() {
+	u.TargetList = common.NewTargetList(u.pick, &common.TargetListOpt{})
+	u.TargetList.Init(compareHeight)
+	u.par = termui.NewPar("")
+	u.par.BorderTop = true
+	u.par.BorderLeft = false
+	u.par.BorderBottom = false
+	u.par.BorderRight = false
+	u.par.Height = compareHeight
+}
+
+// pick the target confirmed as A, then B
+func (u *ui) pick(id int) {
+	if u.a < 0 || u.b >= 0 || u.marker != nil {
+		u.a, u.b = id, -1
+		u.marker = nil
+	} else {
+		u.b = id
+	}
+	u.refreshAt = time.Time{}
+}
+
+// Render see `UI`
+func (u *ui) Render() *termui.Row {
+	return termui.NewRow(
+		termui.NewCol(3, 0, u.TargetList.Render()),
+		termui.NewCol(9, 0, u.par),
+	)
+}
+
+// Activate see `UI`
+func (u *ui) Activate() {
+}
+
+// Deactivate see `UI`
+func (u *ui) Deactivate() {
+}
+
+// ToggleKey activate/deactivate this add-on
+func (u *ui) ToggleKey() string {
+	return "C"
+}
+
+// RespondEvents see `UI`
+func (u *ui) RespondEvents() []types.EventMeta {
+	return []types.EventMeta{
+		{Keys: []string{"<Enter>"}, Description: "pick the target to compare, A then B"},
+		{Keys: []string{"b"}, Description: "compare the selected target before and after the latest marker, again for earlier ones"},
+		{Keys: []string{"r"}, Description: "reset the comparison"},
+	}
+}
+
+// HandleKeyEvent see `UI`
+func (u *ui) HandleKeyEvent(ev termui.Event) {
+	if ev.Type != termui.KeyboardEvent {
+		return
+	}
+	u.hint = ""
+	switch ev.ID {
+	case "b":
+		if id := u.TargetList.CurrentSelected(); id >= 0 {
+			u.splitAtMarker(id)
+		}
+	case "r":
+		u.a, u.b = -1, -1
+		u.marker = nil
+	default:
+		// ignore
+	}
+	u.refreshAt = time.Time{}
+}
+
+// splitAtMarker compares target `id` before and after the latest marker,
+// or the one earlier than the current if splitting it already, wrapped around
+func (u *ui) splitAtMarker(id int) {
+	var markers []event.Event
+	if u.source.events != nil {
+		markers = u.source.events.Markers()
+	}
+	if len(markers) == 0 {
+		u.hint = "no markers, drop one by <M> first"
+		return
+	}
+	pick := len(markers) - 1
+	if u.marker != nil && u.a == id {
+		for i := len(markers) - 1; i >= 0; i-- {
+			if markers[i].Start.Before(u.marker.Start) {
+				pick = i
+				break
+			}
+		}
+	}
+	u.a, u.b = id, id
+	u.marker = &markers[pick]
+}
+
+// ActivateAfterStart see `UI`
+func (u *ui) ActivateAfterStart() bool {
+	return false
+}
+
+// UpdateState see `UI`
+func (u *ui) UpdateState(t time.Time, actives map[int]bool) {
+	u.TargetList.UpdateState(u.source.targets.Titles(), actives)
+	if t.Before(u.refreshAt) {
+		return
+	}
+	u.refreshAt = t.Add(refreshPeriod)
+	u.par.Text = u.compare(t)
+	u.par.Height = compareHeight
+	if n := strings.Count(u.par.Text, "\n") + 2; n > compareHeight {
+		u.par.Height = n
+	}
+}
+
+func (u *ui) sides(t time.Time) (names [2]string, samples [2]*statistic.Sample, ok bool) {
+	stA := u.source.statistic(u.a)
+	if stA == nil {
+		return
+	}
+	if u.marker != nil {
+		before := stA.SampleBetween(time.Time{}, u.marker.Start)
+		after := stA.SampleBetween(u.marker.Start, t)
+		mark := u.marker.Label()
+		return [2]string{"before " + mark, "after " + mark}, [2]*statistic.Sample{&before, &after}, true
+	}
+	stB := u.source.statistic(u.b)
+	if stB == nil {
+		return
+	}
+	a, b := stA.SampleBetween(time.Time{}, t), stB.SampleBetween(time.Time{}, t)
+	return [2]string{stA.Title, stB.Title}, [2]*statistic.Sample{&a, &b}, true
+}
+
+func (u *ui) compare(t time.Time) string {
+	if u.hint != "" {
+		return u.hint
+	}
+	if u.a < 0 {
+		return "<enter> to pick target A, then B; or <b> to compare before and after the latest marker on the selected target"
+	}
+	if u.b < 0 {
+		return fmt.Sprintf("A: %s, <enter> to pick target B", u.source.targets.Title(u.a))
+	}
+	names, samples, ok := u.sides(t)
+	if !ok {
+		return "waiting for records"
+	}
+	a, b := samples[0], samples[1]
+	row := func(label, va, vb string) string {
+		return fmt.Sprintf("%-*s %-*s %-*s", labelWidth, label, columnWidth, va, columnWidth, vb)
+	}
+	lines := []string{
+		fmt.Sprintf("%-*s [%-*s](fg-bold) [%-*s](fg-bold)", labelWidth, "",
+			columnWidth, "A: "+clip(names[0], columnWidth-3), columnWidth, "B: "+clip(names[1], columnWidth-3)),
+		row("records", fmt.Sprint(a.Total), fmt.Sprint(b.Total)),
+		row("loss", fmt.Sprintf("%.2f%%", a.LossRate()*100), fmt.Sprintf("%.2f%%", b.LossRate()*100)),
+	}
+	for _, p := range []float64{50, 90, 99} {
+		lines = append(lines, row(fmt.Sprintf("p%.0f", p), formatCost(a.Percentile(p)), formatCost(b.Percentile(p))))
+	}
+	comparison := statistic.Compare(a, b)
+	lines = append(lines,
+		fmt.Sprintf("%-*s %s", labelWidth, "latency", indicator(comparison.Latency, "slower", "faster")),
+		fmt.Sprintf("%-*s %s", labelWidth, "loss", indicator(comparison.Loss, "lossier", "less lossy")),
+	)
+	lines = append(lines, histograms(a.Histogram(), b.Histogram())...)
+	return strings.Join(lines, "\n")
+}
+
+// histograms of the two samples side by side, only buckets with records
+func histograms(a, b statistic.Histogram) []string {
+	max := 0
+	lo, hi := -1, -1
+	for i := range a {
+		if a[i] > max {
+			max = a[i]
+		}
+		if b[i] > max {
+			max = b[i]
+		}
+		if a[i]+b[i] > 0 {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+	}
+	if lo < 0 {
+		return nil
+	}
+	bar := func(count int) string {
+		if count == 0 {
+			return strings.Repeat(" ", columnWidth)
+		}
+		n := count * maxBarWidth / max
+		if n == 0 {
+			n = 1
+		}
+		s := fmt.Sprintf("%s %d", strings.Repeat("█", n), count)
+		return fmt.Sprintf("[%s](fg-cyan)%s", s, strings.Repeat(" ", columnWidth-len([]rune(s))))
+	}
+	var lines []string
+	for i := hi; i >= lo; i-- {
+		label := "≥" + statistic.LatencyBuckets[len(statistic.LatencyBuckets)-1].String()
+		if i < len(statistic.LatencyBuckets) {
+			label = "<" + statistic.LatencyBuckets[i].String()
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %s %s", labelWidth, label, bar(a[i]), bar(b[i])))
+	}
+	return lines
+}
+
+// indicator of significance, e.g. B is slower significantly
+func indicator(s statistic.Significance, greater, less string) string {
+	if !s.Tested {
+		return "[? not enough samples](fg-yellow)"
+	}
+	if !s.Significant() {
+		return fmt.Sprintf("[= no significant difference, p=%.3f](fg-green)", s.P)
+	}
+	verdict := less
+	if s.Greater {
+		verdict = greater
+	}
+	return fmt.Sprintf("[✘ B is %s significantly, p=%.3f](fg-red,fg-bold)", verdict, s.P)
+}
+
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func formatCost(cost int64) string {
+	if cost == math.MaxInt64 {
+		return "-"
+	}
+	return time.Duration(cost).Truncate(10 * time.Microsecond).String()
+}
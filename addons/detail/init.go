@@ -0,0 +1,7 @@
+package detail
+
+import "github.com/yittg/ving/addons"
+
+func init() {
+	addons.Register(newDetail())
+}
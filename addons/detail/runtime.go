@@ -0,0 +1,57 @@
+package detail
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yittg/ving/addons"
+	"github.com/yittg/ving/statistic"
+)
+
+type runtime struct {
+	targets   *addons.Targets
+	statistic func(int) *statistic.Detail
+	selected  func() int
+
+	ui         *ui
+	initUILock sync.Once
+}
+
+func newDetail() addons.AddOn {
+	return &runtime{}
+}
+
+// Desc of this detail add-on
+func (*runtime) Desc() string {
+	return "latency histogram and heatmap of the target"
+}
+
+// Init see `AddOn.Init`
+func (rt *runtime) Init(envoy *addons.Envoy) {
+	rt.statistic = envoy.Statistic
+	rt.selected = envoy.Selected
+	rt.targets = envoy.Targets
+}
+
+// Start see `AddOn.Start`
+func (rt *runtime) Start(context.Context) {
+}
+
+// Schedule see `AddOn.Schedule`
+func (rt *runtime) Schedule() {
+}
+
+// State see `AddOn.State`, nothing to provide, the statistic is looked up by target
+func (rt *runtime) State() interface{} {
+	return nil
+}
+
+// GetUI see `AddOn.GetUI`
+func (rt *runtime) GetUI() addons.UI {
+	if rt.ui == nil {
+		rt.initUILock.Do(func() {
+			rt.ui = newUI(rt)
+		})
+	}
+	return rt.ui
+}
@@ -0,0 +1,211 @@
+package detail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui"
+	"github.com/yittg/ving/addons/common"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/types"
+)
+
+const (
+	labelWidth = 7
+	// the top border, a summary row, a blank row, and a row per latency bucket
+	detailHeight = 14
+)
+
+// shades of heatmap cell by the fraction of records of the slot in the bucket
+var shades = []struct {
+	below float64
+	shade string
+}{
+	{0.1, "░"},
+	{0.3, "▒"},
+	{0.6, "▓"},
+	{1.1, "█"},
+}
+
+type ui struct {
+	*common.TargetList
+
+	hist *termui.Par
+	heat *termui.Par
+
+	// lastMainSelected follows the selection of main board
+	lastMainSelected int
+
+	source *runtime
+}
+
+func newUI(rt *runtime) *ui {
+	return &ui{
+		lastMainSelected: -1,
+		source:           rt,
+	}
+}
+
+func newPar(label string) *termui.Par {
+	p := termui.NewPar("")
+	p.BorderTop = true
+	p.BorderLeft = false
+	p.BorderBottom = false
+	p.BorderRight = false
+	p.BorderLabel = label
+	p.Height = detailHeight
+	return p
+}
+
+// Init see `UI`
+func (u *ui) Init() {
+	u.TargetList = common.NewTargetList(func(int) {}, &common.TargetListOpt{SelectOnMove: true})
+	u.TargetList.Init(detailHeight)
+	u.hist = newPar(" histogram in window ")
+	u.heat = newPar(" heatmap of session ")
+}
+
+// Render see `UI`
+func (u *ui) Render() *termui.Row {
+	return termui.NewRow(
+		termui.NewCol(3, 0, u.TargetList.Render()),
+		termui.NewCol(4, 0, u.hist),
+		termui.NewCol(5, 0, u.heat),
+	)
+}
+
+// Activate see `UI`
+func (u *ui) Activate() {
+}
+
+// Deactivate see `UI`
+func (u *ui) Deactivate() {
+}
+
+// ToggleKey activate/deactivate this add-on
+func (u *ui) ToggleKey() string {
+	return "d"
+}
+
+// RespondEvents see `UI`
+func (u *ui) RespondEvents() []types.EventMeta {
+	return nil
+}
+
+// HandleKeyEvent see `UI`
+func (u *ui) HandleKeyEvent(termui.Event) {
+}
+
+// ActivateAfterStart see `UI`
+func (u *ui) ActivateAfterStart() bool {
+	return false
+}
+
+// UpdateState see `UI`
+func (u *ui) UpdateState(_ time.Time, actives map[int]bool) {
+	u.TargetList.UpdateState(u.source.targets.Titles(), actives)
+	if selected := u.source.selected(); selected >= 0 && selected != u.lastMainSelected {
+		u.TargetList.Select(selected)
+	}
+	u.lastMainSelected = u.source.selected()
+
+	id := u.TargetList.CurrentSelected()
+	var st *statistic.Detail
+	if id >= 0 {
+		st = u.source.statistic(id)
+	}
+	if st == nil {
+		u.hist.Text = "select a target"
+		u.heat.Text = ""
+		return
+	}
+	u.hist.Text = renderHistogram(st, u.hist.InnerWidth())
+	slots, slotSize := st.Heatmap()
+	u.heat.BorderLabel = fmt.Sprintf(" heatmap of session, %v per column ", slotSize)
+	u.heat.Text = renderHeatmap(slots, u.heat.InnerWidth())
+}
+
+// bucketLabel of the i-th latency bucket
+func bucketLabel(i int) string {
+	if i < len(statistic.LatencyBuckets) {
+		return fmt.Sprintf("<%v", statistic.LatencyBuckets[i])
+	}
+	return fmt.Sprintf("≥%v", statistic.LatencyBuckets[i-1])
+}
+
+// renderHistogram of window, a row per bucket with the highest latency on top, aligned with the heatmap
+func renderHistogram(st *statistic.Detail, width int) string {
+	h := st.WindowHistogram()
+	total, max := 0, 0
+	for _, count := range h {
+		total += count
+		if count > max {
+			max = count
+		}
+	}
+	lines := []string{fmt.Sprintf("%-*s #%d, seq %d, late #%d, dup #%d, reord #%d",
+		labelWidth, "records", total, st.Seq, st.Late, st.Duplicates, st.Reordered), ""}
+	barWidth := width - labelWidth - 8
+	for i := len(h) - 1; i >= 0; i-- {
+		bar := ""
+		if max > 0 && h[i] > 0 && barWidth > 0 {
+			n := h[i] * barWidth / max
+			if n == 0 {
+				n = 1
+			}
+			bar = fmt.Sprintf("[%s](fg-cyan)", strings.Repeat("█", n))
+		}
+		count := ""
+		if h[i] > 0 {
+			count = fmt.Sprintf(" %d", h[i])
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %s%s", labelWidth, bucketLabel(i), bar, count))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func shadeOf(fraction float64) string {
+	if fraction <= 0 {
+		return " "
+	}
+	for _, s := range shades {
+		if fraction < s.below {
+			return s.shade
+		}
+	}
+	return shades[len(shades)-1].shade
+}
+
+// renderHeatmap of latest slots fit in width, a row per bucket with the highest latency on top, and a row of loss
+func renderHeatmap(slots []statistic.HeatmapSlot, width int) string {
+	columns := width - labelWidth - 1
+	if columns <= 0 {
+		return ""
+	}
+	if len(slots) > columns {
+		slots = slots[len(slots)-columns:]
+	}
+	loss := make([]string, len(slots))
+	for i, slot := range slots {
+		loss[i] = " "
+		if slot.Errors > 0 {
+			loss[i] = "x"
+		}
+	}
+	lines := []string{fmt.Sprintf("%-*s [%s](fg-red)", labelWidth, "loss", strings.Join(loss, "")), ""}
+	nBuckets := len(statistic.LatencyBuckets) + 1
+	for b := nBuckets - 1; b >= 0; b-- {
+		var row strings.Builder
+		for _, slot := range slots {
+			total := slot.Histogram.Total()
+			if total == 0 {
+				row.WriteString(" ")
+				continue
+			}
+			row.WriteString(shadeOf(float64(slot.Histogram[b]) / float64(total)))
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %s", labelWidth, bucketLabel(b), row.String()))
+	}
+	return strings.Join(lines, "\n")
+}
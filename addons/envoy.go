@@ -1,19 +1,29 @@
 package addons
 
 import (
+	"github.com/yittg/ving/event"
 	"github.com/yittg/ving/net"
-	"github.com/yittg/ving/net/protocol"
 	"github.com/yittg/ving/options"
+	"github.com/yittg/ving/statistic"
 )
 
 // Envoy for the ability to communicate with engine and add-ons
 type Envoy struct {
-	// Targets is the main targets, namely IP targets
-	Targets []*protocol.NetworkTarget
+	// Targets is the main targets, namely IP targets, which may be added or removed at runtime
+	Targets *Targets
 
 	// Opt is options set when start
 	Opt *options.Option
 
 	// Ping provide major ping ability
 	Ping *net.NPing
+
+	// Events derived from records of main targets
+	Events *event.Log
+
+	// Statistic of main target `id`, nil if no record yet
+	Statistic func(id int) *statistic.Detail
+
+	// Selected target ID in main board, -1 if none
+	Selected func() int
 }
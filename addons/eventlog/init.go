@@ -0,0 +1,7 @@
+package eventlog
+
+import "github.com/yittg/ving/addons"
+
+func init() {
+	addons.Register(newEventLog())
+}
@@ -0,0 +1,53 @@
+package eventlog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yittg/ving/addons"
+	"github.com/yittg/ving/event"
+)
+
+type runtime struct {
+	log *event.Log
+
+	ui         *ui
+	initUILock sync.Once
+}
+
+func newEventLog() addons.AddOn {
+	return &runtime{}
+}
+
+// Desc of this event log add-on
+func (*runtime) Desc() string {
+	return "event log of outages and incidents"
+}
+
+// Init see `AddOn.Init`
+func (rt *runtime) Init(envoy *addons.Envoy) {
+	rt.log = envoy.Events
+}
+
+// Start see `AddOn.Start`
+func (rt *runtime) Start(context.Context) {
+}
+
+// Schedule see `AddOn.Schedule`
+func (rt *runtime) Schedule() {
+}
+
+// State returns all events kept
+func (rt *runtime) State() interface{} {
+	return rt.log.Events()
+}
+
+// GetUI see `AddOn.GetUI`
+func (rt *runtime) GetUI() addons.UI {
+	if rt.ui == nil {
+		rt.initUILock.Do(func() {
+			rt.ui = newUI(rt)
+		})
+	}
+	return rt.ui
+}
@@ -0,0 +1,151 @@
+package eventlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui"
+	"github.com/yittg/ving/event"
+	"github.com/yittg/ving/types"
+)
+
+const eventLogHeight = 10
+
+type ui struct {
+	list *termui.List
+
+	// offset of the newest event displayed, scrolled by up/down
+	offset      int
+	ongoingOnly bool
+
+	source *runtime
+}
+
+func newUI(rt *runtime) *ui {
+	return &ui{
+		source: rt,
+	}
+}
+
+// Render see `UI`
+func (u *ui) Render() *termui.Row {
+	return termui.NewRow(
+		termui.NewCol(12, 0, u.list),
+	)
+}
+
+// Init see `UI`
+func (u *ui) Init() {
+	u.list = termui.NewList()
+	u.list.BorderTop = true
+	u.list.BorderLeft = false
+	u.list.BorderBottom = false
+	u.list.BorderRight = false
+	u.list.BorderLabel = " events "
+	u.list.Height = eventLogHeight
+}
+
+// Activate see `UI`
+func (u *ui) Activate() {
+}
+
+// Deactivate see `UI`
+func (u *ui) Deactivate() {
+}
+
+// ToggleKey activate/deactivate this add-on
+func (u *ui) ToggleKey() string {
+	return "e"
+}
+
+// RespondEvents see `UI`
+func (u *ui) RespondEvents() []types.EventMeta {
+	return []types.EventMeta{
+		{Keys: []string{"f"}, Description: "filter events, ongoing only or all"},
+	}
+}
+
+// HandleKeyEvent see `UI`
+func (u *ui) HandleKeyEvent(ev termui.Event) {
+	if ev.Type != termui.KeyboardEvent {
+		return
+	}
+	switch ev.ID {
+	case "f":
+		u.ongoingOnly = !u.ongoingOnly
+		u.offset = 0
+	default:
+		// ignore
+	}
+}
+
+// OnUp see `VerticalDirectionAware`
+func (u *ui) OnUp() {
+	if u.offset > 0 {
+		u.offset--
+	}
+}
+
+// OnDown see `VerticalDirectionAware`
+func (u *ui) OnDown() {
+	u.offset++
+}
+
+// ActivateAfterStart see `UI`
+func (u *ui) ActivateAfterStart() bool {
+	return false
+}
+
+func eventColor(e *event.Event) string {
+	if e.Kind == event.Marker {
+		return "fg-cyan"
+	}
+	if e.Kind == event.AddressChanged {
+		return "fg-blue"
+	}
+	if !e.Ongoing() {
+		return "fg-green"
+	}
+	if e.Kind == event.Down {
+		return "fg-red"
+	}
+	return "fg-yellow"
+}
+
+// UpdateState see `UI`
+func (u *ui) UpdateState(t time.Time, _ map[int]bool) {
+	events, ok := u.source.State().([]event.Event)
+	if !ok {
+		return
+	}
+	var items []string
+	// newest first
+	for i := len(events) - 1; i >= 0; i-- {
+		e := &events[i]
+		if u.ongoingOnly && !e.Ongoing() {
+			continue
+		}
+		items = append(items, fmt.Sprintf("[%s](%s)", e.Summary(t), eventColor(e)))
+	}
+	if len(items) == 0 {
+		u.offset = 0
+		u.list.Items = []string{"no events yet"}
+		return
+	}
+	rows := u.list.Height - 1
+	if max := len(items) - rows; u.offset > max {
+		u.offset = max
+	}
+	if u.offset < 0 {
+		u.offset = 0
+	}
+	u.list.Items = items[u.offset:]
+}
+
+// Indicator see `addons.Indicator`, shows count of ongoing events
+func (u *ui) Indicator() string {
+	if n := u.source.log.Ongoing(); n > 0 {
+		return fmt.Sprintf("[incidents #%d](fg-red,fg-bold)", n)
+	}
+	return ""
+}
@@ -2,15 +2,124 @@ package config
 
 import (
 	"fmt"
+	"path"
+	"time"
 
 	"github.com/yittg/ving/addons/port/types"
+	c "github.com/yittg/ving/config/encoding"
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/utils/slices"
+)
+
+// Provided profiles
+const (
+	// DefaultProfile name of the profile consists of well known ports and extra ports
+	DefaultProfile = "default"
+
+	// PolicyProfile name of the profile consists of ports in policies
+	PolicyProfile = "policy"
 )
 
 // PortsConfig for custom
 type PortsConfig struct {
 	Extra            []types.PortDesc
-	ProbeConcurrency int `toml:"probe-concurrency"`
+	ProbeConcurrency int        `toml:"probe-concurrency"`
+	Rate             float64    `toml:"rate"`
+	TargetRate       float64    `toml:"target-rate"`
+	Timeout          c.Duration `toml:"timeout"`
+	Retries          int        `toml:"retries"`
+	BackoffThresh    float64    `toml:"backoff-thresh"`
+	BannerWait       c.Duration `toml:"banner-wait"`
+	Profiles         map[string]Profile
+	Policies         []Policy
+}
+
+// Profile represents a named set of ports
+type Profile struct {
+	Ports []types.PortDesc
+}
+
+// Policy represents the expected reachability of ports of targets
+type Policy struct {
+	// Targets patterns to match addresses or names of targets, see `path.Match`
+	Targets []string
+	// Groups to match targets in, namely the group or one of tags, and Tags to match targets tagged
+	Groups []string
+	Tags   []string
+	Open   []int
+	Closed []int
+}
+
+// Match checks whether the policy applies to the target of address, name, group and tags,
+// it applies if any of patterns, groups and tags matches
+func (p *Policy) Match(address, name, group string, tags []string) bool {
+	for _, pattern := range p.Targets {
+		if matched, _ := path.Match(pattern, address); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, name); matched && name != "" {
+			return true
+		}
+	}
+	for _, g := range p.Groups {
+		if g == group || slices.ContainStr(tags, g) {
+			return true
+		}
+	}
+	for _, tag := range p.Tags {
+		if slices.ContainStr(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) validate(idx int) error {
+	if len(p.Targets) == 0 && len(p.Groups) == 0 && len(p.Tags) == 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("no targets, groups or tags of ports policy, (policies[%d])", idx),
+		}
+	}
+	for _, pattern := range p.Targets {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &errors.ConfigError{
+				Msg: fmt.Sprintf("invalid target pattern of ports policy, (policies[%d], targets=%q)", idx, pattern),
+			}
+		}
+	}
+	open := make(map[int]bool, len(p.Open))
+	for _, port := range p.Open {
+		open[port] = true
+	}
+	for _, ports := range [][]int{p.Open, p.Closed} {
+		for _, port := range ports {
+			if port <= 0 || port > 65535 {
+				return &errors.ConfigError{
+					Msg: fmt.Sprintf("invalid port in ports policy, (policies[%d], port=%d)", idx, port),
+				}
+			}
+		}
+	}
+	for _, port := range p.Closed {
+		if open[port] {
+			return &errors.ConfigError{
+				Msg: fmt.Sprintf("port expected to be both open and closed, (policies[%d], port=%d)", idx, port),
+			}
+		}
+	}
+	return nil
+}
+
+// HasProfile checks whether the profile named `name` exists
+func (c *PortsConfig) HasProfile(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	if name == PolicyProfile {
+		return len(c.Policies) > 0
+	}
+	_, ok := c.Profiles[name]
+	return ok
 }
 
 // Validate ports config
@@ -20,6 +129,55 @@ func (c *PortsConfig) Validate() error {
 			Msg: fmt.Sprintf("ports probe concurrency should in range [1,1023], (probe-concurrency=%d)", c.ProbeConcurrency),
 		}
 	}
+	if c.Rate < 0 || c.TargetRate < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("ports probe rate should not be negative, (rate=%v, target-rate=%v)", c.Rate, c.TargetRate),
+		}
+	}
+	if c.Timeout.Value < 10*time.Millisecond {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("ports probe timeout should not be shorter than 10ms, (timeout=%v)", c.Timeout.Value),
+		}
+	}
+	if c.BannerWait.Value < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("ports banner wait should not be negative, (banner-wait=%v)", c.BannerWait.Value),
+		}
+	}
+	if c.Retries < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("ports probe retries should not be negative, (retries=%d)", c.Retries),
+		}
+	}
+	if c.BackoffThresh < 0 || c.BackoffThresh > 1 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("ports probe backoff threshold should in range [0,1], (backoff-thresh=%v)", c.BackoffThresh),
+		}
+	}
+	for name, profile := range c.Profiles {
+		if name == "" || name == DefaultProfile || name == PolicyProfile {
+			return &errors.ConfigError{
+				Msg: fmt.Sprintf("invalid ports profile name, (profiles.%q)", name),
+			}
+		}
+		if len(profile.Ports) == 0 {
+			return &errors.ConfigError{
+				Msg: fmt.Sprintf("empty ports profile, (profiles.%s)", name),
+			}
+		}
+		for _, pd := range profile.Ports {
+			if pd.Port <= 0 || pd.Port > 65535 {
+				return &errors.ConfigError{
+					Msg: fmt.Sprintf("invalid port in profile, (profiles.%s, port=%d)", name, pd.Port),
+				}
+			}
+		}
+	}
+	for idx := range c.Policies {
+		if err := c.Policies[idx].validate(idx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -27,5 +185,39 @@ func (c *PortsConfig) Validate() error {
 func Default() PortsConfig {
 	return PortsConfig{
 		ProbeConcurrency: 1023,
+		Timeout: c.Duration{
+			Value: time.Second,
+		},
+		BackoffThresh: 0.5,
+		Profiles: map[string]Profile{
+			"web": {Ports: []types.PortDesc{
+				{Name: "http", Port: 80},
+				{Name: "https", Port: 443},
+				{Name: "http-alt", Port: 8000},
+				{Name: "http(8008)", Port: 8008},
+				{Name: "http(8080)", Port: 8080},
+				{Name: "https(8443)", Port: 8443},
+			}},
+			"databases": {Ports: []types.PortDesc{
+				{Name: "mssql", Port: 1433},
+				{Name: "oracle", Port: 1521},
+				{Name: "mysql", Port: 3306},
+				{Name: "PostgreSQL", Port: 5432},
+				{Name: "redis", Port: 6379},
+				{Name: "cassandra", Port: 9042},
+				{Name: "elasticsearch", Port: 9200},
+				{Name: "memcached", Port: 11211},
+				{Name: "mongodb", Port: 27017},
+			}},
+			"k8s": {Ports: []types.PortDesc{
+				{Name: "etcd", Port: 2379},
+				{Name: "etcd(peer)", Port: 2380},
+				{Name: "apiserver", Port: 6443},
+				{Name: "kubelet", Port: 10250},
+				{Name: "scheduler", Port: 10259},
+				{Name: "controller", Port: 10257},
+				{Name: "kube-proxy", Port: 10256},
+			}},
+		},
 	}
 }
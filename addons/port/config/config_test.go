@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestPolicyMatch(t *testing.T) {
+	p := Policy{Targets: []string{"10.0.1.*", "db-*"}, Groups: []string{"prod"}, Tags: []string{"dmz"}}
+	cases := []struct {
+		address, name, group string
+		tags                 []string
+		expected             bool
+	}{
+		{"10.0.1.3", "", "", nil, true},
+		{"10.0.2.3", "db-1", "", nil, true},
+		{"10.0.2.3", "web-1", "prod", nil, true},
+		{"10.0.2.3", "web-1", "", []string{"dc1", "prod"}, true},
+		{"10.0.2.3", "web-1", "test", []string{"dmz"}, true},
+		{"10.0.2.3", "web-1", "test", []string{"dc1"}, false},
+	}
+	for _, c := range cases {
+		if matched := p.Match(c.address, c.name, c.group, c.tags); matched != c.expected {
+			t.Errorf("expect matched %v of %+v, but got %v", c.expected, c, matched)
+		}
+	}
+	if err := (&Policy{Groups: []string{"prod"}, Closed: []int{23}}).validate(0); err != nil {
+		t.Errorf("expect policy of groups valid, but got %v", err)
+	}
+	if err := (&Policy{Closed: []int{23}}).validate(0); err == nil {
+		t.Error("expect error of policy matching nothing")
+	}
+}
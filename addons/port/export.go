@@ -0,0 +1,256 @@
+package port
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yittg/ving/net/protocol"
+)
+
+// Supported export formats
+const (
+	exportJSON = "json"
+	exportCSV  = "csv"
+	exportGrep = "grep"
+)
+
+// exportBannerWait is how long to wait for the banner when exporting, if banner wait not configured
+const exportBannerWait = 200 * time.Millisecond
+
+var exportExtensions = map[string]string{
+	exportJSON: "json",
+	exportCSV:  "csv",
+	exportGrep: "gnmap",
+}
+
+// exportRecord represents result of a single port of a target
+type exportRecord struct {
+	Host    string  `json:"host"`
+	Address string  `json:"address"`
+	Port    int     `json:"port"`
+	Proto   string  `json:"proto"`
+	State   string  `json:"state"`
+	Service string  `json:"service"`
+	Latency float64 `json:"latency_ms"`
+	Banner  string  `json:"banner"`
+}
+
+// exportMarker represents a marker dropped in the session
+type exportMarker struct {
+	At   time.Time `json:"at"`
+	Note string    `json:"note"`
+}
+
+// exportPayload of json format, results of ports with markers
+type exportPayload struct {
+	Ports   []exportRecord `json:"ports"`
+	Markers []exportMarker `json:"markers"`
+}
+
+type exportRequest struct {
+	all bool
+}
+
+// exportFormatOf decides the format by `format` specified, or extension of `path`
+func exportFormatOf(path, format string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", "":
+		return exportJSON
+	case ".csv":
+		return exportCSV
+	default:
+		return exportGrep
+	}
+}
+
+func stateOf(res *touchResult) string {
+	switch {
+	case res == nil:
+		return "unchecked"
+	case res.connected:
+		return "open"
+	case res.timeout:
+		return "filtered"
+	default:
+		return "closed"
+	}
+}
+
+func (rt *runtime) buildExportRecords(ids []int) []exportRecord {
+	var records []exportRecord
+	for _, id := range ids {
+		address := ""
+		if t := rt.targets.Get(id); t != nil && t.Typ == protocol.IP {
+			address = t.Target.(*net.IPAddr).String()
+		}
+		for _, trw := range rt.results[id] {
+			record := exportRecord{
+				Host:    rt.targets.Raw(id),
+				Address: address,
+				Port:    trw.port.Port,
+				Proto:   "tcp",
+				State:   stateOf(trw.res),
+				Service: trw.port.Name,
+			}
+			if trw.res != nil {
+				record.Latency = float64(trw.res.connTime) / float64(time.Millisecond)
+				record.Banner = trw.res.banner
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func (rt *runtime) buildExportMarkers() []exportMarker {
+	if rt.events == nil {
+		return nil
+	}
+	var markers []exportMarker
+	for _, m := range rt.events.Markers() {
+		markers = append(markers, exportMarker{At: m.Start, Note: m.Detail})
+	}
+	return markers
+}
+
+func writeJSON(w io.Writer, records []exportRecord, markers []exportMarker) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	payload := exportPayload{Ports: records, Markers: markers}
+	if payload.Ports == nil {
+		payload.Ports = []exportRecord{}
+	}
+	if payload.Markers == nil {
+		payload.Markers = []exportMarker{}
+	}
+	return encoder.Encode(payload)
+}
+
+// writeMarkerComments writes markers as comment lines like `# marker 2006-01-02T15:04:05Z07:00 note`
+func writeMarkerComments(w io.Writer, markers []exportMarker) error {
+	for _, m := range markers {
+		if _, err := fmt.Fprintf(w, "# marker %s %s\n", m.At.Format(time.RFC3339), m.Note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes markers as leading comment lines, then a row per port
+func writeCSV(w io.Writer, records []exportRecord, markers []exportMarker) error {
+	if err := writeMarkerComments(w, markers); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"host", "address", "port", "proto", "state", "service", "latency_ms", "banner"}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{r.Host, r.Address, strconv.Itoa(r.Port), r.Proto, r.State, r.Service,
+			strconv.FormatFloat(r.Latency, 'f', 3, 64), r.Banner}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeGrep writes markers as leading comment lines, then a line per host like nmap greppable output,
+// each port formatted as port/state/proto//service/latency/banner/
+func writeGrep(w io.Writer, records []exportRecord, markers []exportMarker) error {
+	if err := writeMarkerComments(w, markers); err != nil {
+		return err
+	}
+	escape := strings.NewReplacer("/", "|", ",", ";")
+	var host string
+	var ports []string
+	flush := func() error {
+		if len(ports) == 0 {
+			return nil
+		}
+		_, err := fmt.Fprintf(w, "Host: %s\tPorts: %s\n", host, strings.Join(ports, ", "))
+		ports = nil
+		return err
+	}
+	for _, r := range records {
+		h := fmt.Sprintf("%s (%s)", r.Address, r.Host)
+		if h != host {
+			if err := flush(); err != nil {
+				return err
+			}
+			host = h
+		}
+		latency := ""
+		if r.State == "open" {
+			latency = strconv.FormatFloat(r.Latency, 'f', 3, 64) + "ms"
+		}
+		ports = append(ports, fmt.Sprintf("%d/%s/%s//%s/%s/%s/",
+			r.Port, r.State, r.Proto, escape.Replace(r.Service), latency, escape.Replace(r.Banner)))
+	}
+	return flush()
+}
+
+func writeRecords(w io.Writer, format string, records []exportRecord, markers []exportMarker) error {
+	switch format {
+	case exportJSON:
+		return writeJSON(w, records, markers)
+	case exportCSV:
+		return writeCSV(w, records, markers)
+	case exportGrep:
+		return writeGrep(w, records, markers)
+	default:
+		return fmt.Errorf("unsupported export format, %s", format)
+	}
+}
+
+func (rt *runtime) exportIDs(all bool) []int {
+	if !all {
+		if _, ok := rt.results[rt.currentSelected()]; !ok {
+			return nil
+		}
+		return []int{rt.currentSelected()}
+	}
+	ids := make([]int, 0, len(rt.results))
+	for id := range rt.results {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// export results of selected target or all targets into file
+func (rt *runtime) export(all bool) (string, error) {
+	format := exportFormatOf(rt.opt.PortsExport, rt.opt.PortsExportFormat)
+	path := rt.opt.PortsExport
+	if path == "" {
+		path = fmt.Sprintf("ving-ports-%s.%s", time.Now().Format("20060102-150405"), exportExtensions[format])
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return path, err
+	}
+	defer f.Close()
+	return path, writeRecords(f, format, rt.buildExportRecords(rt.exportIDs(all)), rt.buildExportMarkers())
+}
+
+func (rt *runtime) doExport(all bool) {
+	path, err := rt.export(all)
+	if err != nil {
+		rt.exportMsg = fmt.Sprintf("[export failed, %s](fg-red)", err)
+	} else {
+		rt.exportMsg = fmt.Sprintf("[exported to %s](fg-green)", path)
+	}
+	rt.exportAt = time.Now()
+}
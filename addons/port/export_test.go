@@ -0,0 +1,58 @@
+package port
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWriteGrep(t *testing.T) {
+	records := []exportRecord{
+		{Host: "db1", Address: "10.0.0.1", Port: 22, Proto: "tcp", State: "open", Service: "ssh", Latency: 1.5, Banner: "SSH-2.0-OpenSSH_7.4"},
+		{Host: "db1", Address: "10.0.0.1", Port: 6379, Proto: "tcp", State: "filtered", Service: "redis"},
+		{Host: "db2", Address: "10.0.0.2", Port: 22, Proto: "tcp", State: "closed", Service: "ssh"},
+	}
+	markers := []exportMarker{{At: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC), Note: "switched to backup link"}}
+	buf := &bytes.Buffer{}
+	if err := writeGrep(buf, records, markers); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# marker 2026-10-01T08:00:00Z switched to backup link\n" +
+		"Host: 10.0.0.1 (db1)\tPorts: 22/open/tcp//ssh/1.500ms/SSH-2.0-OpenSSH_7.4/, 6379/filtered/tcp//redis///\n" +
+		"Host: 10.0.0.2 (db2)\tPorts: 22/closed/tcp//ssh///\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected greppable output:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	records := []exportRecord{{Host: "db1", Address: "10.0.0.1", Port: 22, Proto: "tcp", State: "open", Service: "ssh"}}
+	markers := []exportMarker{{At: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC), Note: "switched to backup link"}}
+	buf := &bytes.Buffer{}
+	if err := writeJSON(buf, records, markers); err != nil {
+		t.Fatal(err)
+	}
+	var payload exportPayload
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Ports) != 1 || len(payload.Markers) != 1 || payload.Markers[0].Note != "switched to backup link" {
+		t.Errorf("unexpected json payload %+v", payload)
+	}
+}
+
+func TestExportFormatOf(t *testing.T) {
+	cases := map[[2]string]string{
+		{"ports.json", ""}:   exportJSON,
+		{"ports.CSV", ""}:    exportCSV,
+		{"ports.gnmap", ""}:  exportGrep,
+		{"", ""}:             exportJSON,
+		{"ports.txt", "csv"}: exportCSV,
+	}
+	for c, expected := range cases {
+		if f := exportFormatOf(c[0], c[1]); f != expected {
+			t.Errorf("expect %s for %v, got %s", expected, c, f)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package port
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(10, 2)
+	for i := 0; i < 2; i++ {
+		if d := l.reserve(now); d != 0 {
+			t.Fatalf("expect burst token available, wait %v", d)
+		}
+	}
+	if d := l.reserve(now); d != 100*time.Millisecond {
+		t.Fatalf("expect to wait 100ms, got %v", d)
+	}
+	if d := l.reserve(now.Add(50 * time.Millisecond)); d != 50*time.Millisecond {
+		t.Fatalf("expect to wait 50ms, got %v", d)
+	}
+	if d := l.reserve(now.Add(100 * time.Millisecond)); d != 0 {
+		t.Fatalf("expect token available after 100ms, wait %v", d)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(0, 1)
+	for i := 0; i < 100; i++ {
+		if d := l.reserve(now); d != 0 {
+			t.Fatalf("expect unlimited, wait %v", d)
+		}
+	}
+}
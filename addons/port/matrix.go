@@ -0,0 +1,158 @@
+package port
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yittg/ving/net/protocol"
+)
+
+const (
+	matrixCellWidth   = 6
+	matrixNameWidth   = 24
+	matrixExtraHeight = 3
+)
+
+func formatConnTime(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1ms"
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+func (pu *ui) buildMatrixCell(id int, trw touchResultWrapper, width int) string {
+	format := fmt.Sprintf("%%-%ds", width)
+	res := trw.res
+	if res == nil {
+		return fmt.Sprintf("[%s](fg-grey)", fmt.Sprintf(format, "•"))
+	}
+	if pu.source.violates(id, trw) {
+		text := "✘"
+		if res.connected {
+			text = formatConnTime(res.connTime)
+		}
+		return fmt.Sprintf("[%s](fg-magenta,fg-bold)", fmt.Sprintf(format, "!"+text))
+	}
+	if res.connected {
+		return fmt.Sprintf("[%s](fg-green)", fmt.Sprintf(format, formatConnTime(res.connTime)))
+	}
+	return fmt.Sprintf("[%s](fg-red)", fmt.Sprintf(format, "✘"))
+}
+
+// matrixColumns choose ports shown as columns, which match the filter for at least one target
+func (pu *ui) matrixColumns(st map[int][]touchResultWrapper, rows []int) []int {
+	predicate := pu.getPredicat()
+	var columns []int
+	for portID := range pu.source.targetPorts {
+		for _, id := range rows {
+			var res *touchResult
+			if s, ok := st[id]; ok {
+				res = s[portID].res
+			}
+			if predicate(res) {
+				columns = append(columns, portID)
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func (pu *ui) updateMatrix(t time.Time, st map[int][]touchResultWrapper, actives map[int]bool) {
+	var rows []int
+	nameWidth := 0
+	// only IP targets are scanned
+	pu.source.targets.Each(func(id int, target *protocol.NetworkTarget) {
+		if !actives[id] || target.Typ != protocol.IP {
+			return
+		}
+		rows = append(rows, id)
+		if title := target.Title(); len(title) > nameWidth {
+			nameWidth = len(title)
+		}
+	})
+	if nameWidth > matrixNameWidth {
+		nameWidth = matrixNameWidth
+	}
+	nameWidth += 2
+
+	columns := pu.matrixColumns(st, rows)
+	totalColumns := len(columns)
+	if pu.colOffset >= len(columns) && len(columns) > 0 {
+		pu.colOffset = len(columns) - 1
+	}
+	cellWidth := matrixCellWidth
+	for _, portID := range columns {
+		if l := len(pu.buildPortView(pu.source.targetPorts[portID])); l > cellWidth {
+			cellWidth = l
+		}
+	}
+	cellWidth++
+	visible := (pu.par.Width - nameWidth - 3) / cellWidth
+	if visible < 1 {
+		visible = 1
+	}
+	end := pu.colOffset + visible
+	if end > len(columns) {
+		end = len(columns)
+	}
+	if len(columns) > 0 {
+		columns = columns[pu.colOffset:end]
+	}
+
+	done := 0
+	nameFormat := fmt.Sprintf("%%-%ds", nameWidth)
+	cellFormat := fmt.Sprintf("%%-%ds", cellWidth)
+	lines := make([]string, 0, len(rows)+2)
+
+	header := fmt.Sprintf(nameFormat, "")
+	for _, portID := range columns {
+		header += fmt.Sprintf(cellFormat, pu.buildPortView(pu.source.targetPorts[portID]))
+	}
+	lines = append(lines, "[ "+header+"](fg-bold)")
+
+	selected := pu.CurrentSelected()
+	for _, id := range rows {
+		flag := " "
+		if pu.source.checkDone(id) {
+			flag = "[✔](fg-green)"
+			done++
+		} else if !pu.source.checkNotBegin(id) {
+			flag = pu.rotatingFlag(t)
+		}
+		name := pu.source.targets.Title(id)
+		if len(name) > nameWidth-2 {
+			name = name[:nameWidth-2]
+		}
+		name = fmt.Sprintf(nameFormat, name)
+		if id == selected {
+			name = fmt.Sprintf("[%s](fg-yellow)", name)
+		}
+		line := flag + name
+		thisSt := st[id]
+		for _, portID := range columns {
+			trw := touchResultWrapper{port: pu.source.targetPorts[portID]}
+			if thisSt != nil {
+				trw = thisSt[portID]
+			}
+			line += pu.buildMatrixCell(id, trw, cellWidth)
+		}
+		lines = append(lines, line)
+	}
+
+	summary := fmt.Sprintf("Matrix [%s](fg-bold), done #%d/%d", pu.source.currentProfile(), done, len(rows))
+	if len(columns) < totalColumns {
+		summary += fmt.Sprintf(", ports %d-%d of %d, <left>/<right> to scroll",
+			pu.colOffset+1, pu.colOffset+len(columns), totalColumns)
+	}
+	pu.par.Text = summary + " " + pu.exportMessage(t) + "\n" + strings.Join(lines, "\n")
+	height := len(lines) + matrixExtraHeight
+	if height < portsHeight {
+		height = portsHeight
+	}
+	pu.par.Height = height
+}
@@ -0,0 +1,194 @@
+package port
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yittg/ving/addons/port/types"
+	"github.com/yittg/ving/config"
+	"github.com/yittg/ving/net/protocol"
+)
+
+const maxViolatedTargetsShown = 3
+
+// expectation of ports of a target, represents whether the port is expected to be open
+type expectation map[int]bool
+
+// expectationOf the target by policies matching its address, name, group or tags
+func expectationOf(target *protocol.NetworkTarget) expectation {
+	var e expectation
+	for _, policy := range config.GetConfig().AddOns.Ports.Policies {
+		if !policy.Match(target.Raw, target.Name, target.Group, target.Tags) {
+			continue
+		}
+		if e == nil {
+			e = make(expectation)
+		}
+		for _, port := range policy.Open {
+			e[port] = true
+		}
+		for _, port := range policy.Closed {
+			e[port] = false
+		}
+	}
+	return e
+}
+
+// expectationFor target `id`, nil if no policy matched
+func (rt *runtime) expectationFor(id int) expectation {
+	if e, ok := rt.expectations.Load(id); ok {
+		return e.(expectation)
+	}
+	target := rt.targets.Get(id)
+	if target == nil {
+		return nil
+	}
+	e := expectationOf(target)
+	rt.expectations.Store(id, e)
+	return e
+}
+
+type violation struct {
+	port       types.PortDesc
+	expectOpen bool
+}
+
+func (v *violation) String() string {
+	if v.expectOpen {
+		return fmt.Sprintf("%d(%s) expected open, but not reachable", v.port.Port, v.port.Name)
+	}
+	return fmt.Sprintf("%d(%s) expected closed, but open", v.port.Port, v.port.Name)
+}
+
+// compliance of a target with its expectation
+type compliance struct {
+	pending    int
+	violations []violation
+}
+
+// violates checks whether the result of the port violates the expectation of target `id`
+func (rt *runtime) violates(id int, trw touchResultWrapper) bool {
+	expectOpen, ok := rt.expectationFor(id)[trw.port.Port]
+	return ok && trw.res != nil && trw.res.connected != expectOpen
+}
+
+// complianceOf target `id`, nil if no expectation or not probed yet
+func (rt *runtime) complianceOf(id int) *compliance {
+	e := rt.expectationFor(id)
+	s, ok := rt.results[id]
+	if e == nil || !ok {
+		return nil
+	}
+	c := &compliance{}
+	for _, trw := range s {
+		expectOpen, ok := e[trw.port.Port]
+		if !ok {
+			continue
+		}
+		if trw.res == nil {
+			c.pending++
+			continue
+		}
+		if trw.res.connected != expectOpen {
+			c.violations = append(c.violations, violation{port: trw.port, expectOpen: expectOpen})
+		}
+	}
+	return c
+}
+
+// policyIndicator represents overall compliance of all targets probed
+func (rt *runtime) policyIndicator() string {
+	checked, pending, violations := 0, 0, 0
+	var violated []string
+	rt.targets.Each(func(id int, t *protocol.NetworkTarget) {
+		c := rt.complianceOf(id)
+		if c == nil {
+			return
+		}
+		checked++
+		pending += c.pending
+		if len(c.violations) > 0 {
+			violations += len(c.violations)
+			violated = append(violated, t.Raw)
+		}
+	})
+	if checked == 0 {
+		return ""
+	}
+	checking := ""
+	if pending > 0 {
+		checking = ", checking"
+	}
+	if violations > 0 {
+		if len(violated) > maxViolatedTargetsShown {
+			violated = append(violated[:maxViolatedTargetsShown], "...")
+		}
+		return fmt.Sprintf("[policy ✘ #%d violations of %s%s](fg-magenta,fg-bold)",
+			violations, strings.Join(violated, ","), checking)
+	}
+	if pending > 0 {
+		return fmt.Sprintf("[policy checking #%d targets](fg-yellow)", checked)
+	}
+	return fmt.Sprintf("[policy ✔ #%d targets](fg-green)", checked)
+}
+
+// Involved see `addons.Headless`
+func (rt *runtime) Involved() bool {
+	return rt.opt.Ports
+}
+
+// Finished see `addons.Headless`
+func (rt *runtime) Finished() bool {
+	return rt.scanDone() && (rt.opt.PortsExport == "" || rt.exported)
+}
+
+// Report see `addons.Headless`, prints ports of all targets, and violations of policies
+func (rt *runtime) Report(w io.Writer) bool {
+	compliant := true
+	rt.targets.Each(func(id int, target *protocol.NetworkTarget) {
+		if target.Typ != protocol.IP {
+			if rt.expectationFor(id) != nil {
+				compliant = false
+				fmt.Fprintf(w, "%s\n    ✘ unable to check, %v\n", target.Raw, target.Target)
+			}
+			return
+		}
+		s, ok := rt.results[id]
+		if !ok {
+			return
+		}
+		states := make(map[string][]string)
+		for _, trw := range s {
+			state := stateOf(trw.res)
+			states[state] = append(states[state], portLabel(trw.port))
+		}
+		fmt.Fprintf(w, "%s\n", target.Raw)
+		for _, state := range []string{"open", "closed", "filtered", "unchecked"} {
+			if len(states[state]) > 0 {
+				fmt.Fprintf(w, "    %-10s %s\n", state, strings.Join(states[state], " "))
+			}
+		}
+		if c := rt.complianceOf(id); c != nil {
+			for _, v := range c.violations {
+				compliant = false
+				fmt.Fprintf(w, "    ✘ %s\n", v.String())
+			}
+		}
+	})
+	if len(config.GetConfig().AddOns.Ports.Policies) > 0 {
+		if compliant {
+			fmt.Fprintln(w, "ports policy: compliant")
+		} else {
+			fmt.Fprintln(w, "ports policy: violated")
+		}
+	}
+	return compliant
+}
+
+func portLabel(p types.PortDesc) string {
+	if p.Name == "" || strings.Contains(p.Name, fmt.Sprint(p.Port)) {
+		return fmt.Sprint(p.Port)
+	}
+	return fmt.Sprintf("%d(%s)", p.Port, p.Name)
+}
@@ -4,10 +4,14 @@ import (
 	"sort"
 	"strconv"
 
+	pc "github.com/yittg/ving/addons/port/config"
 	"github.com/yittg/ving/addons/port/types"
 	"github.com/yittg/ving/config"
 )
 
+// customProfile represents ports specified by command line
+const customProfile = "custom"
+
 var wellKnownPorts = []types.PortDesc{
 	{Name: "ssh", Port: 22},
 	{Name: "http", Port: 80},
@@ -65,8 +69,61 @@ func getPredefinedPortByN(port int) *types.PortDesc {
 
 func getNameOfPort(port int) string {
 	pd := getPredefinedPortByN(port)
-	if pd == nil {
-		return strconv.Itoa(port)
+	if pd != nil {
+		return pd.Name
+	}
+	if name, ok := lookupService(port); ok {
+		return name
+	}
+	return strconv.Itoa(port)
+}
+
+// getProfileNames returns all profile names, the default one first
+func getProfileNames() []string {
+	profiles := config.GetConfig().AddOns.Ports.Profiles
+	names := make([]string, 0, len(profiles)+1)
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(config.GetConfig().AddOns.Ports.Policies) > 0 {
+		names = append(names, pc.PolicyProfile)
+	}
+	return append([]string{pc.DefaultProfile}, names...)
+}
+
+func getPolicyProfile() pc.Profile {
+	var profile pc.Profile
+	existed := make(map[int]bool)
+	for _, policy := range config.GetConfig().AddOns.Ports.Policies {
+		for _, ports := range [][]int{policy.Open, policy.Closed} {
+			for _, port := range ports {
+				if existed[port] {
+					continue
+				}
+				existed[port] = true
+				profile.Ports = append(profile.Ports, types.PortDesc{Port: port})
+			}
+		}
+	}
+	return profile
+}
+
+func getPortsOfProfile(name string) []types.PortDesc {
+	if name == pc.DefaultProfile {
+		return getPredefinedPorts()
+	}
+	profile := config.GetConfig().AddOns.Ports.Profiles[name]
+	if name == pc.PolicyProfile {
+		profile = getPolicyProfile()
+	}
+	ports := make(sortable, 0, len(profile.Ports))
+	for _, pd := range profile.Ports {
+		if pd.Name == "" {
+			pd.Name = getNameOfPort(pd.Port)
+		}
+		ports = append(ports, pd)
 	}
-	return pd.Name
+	sort.Sort(ports)
+	return ports
 }
@@ -0,0 +1,317 @@
+package port
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	portconfig "github.com/yittg/ving/addons/port/config"
+	vnet "github.com/yittg/ving/net"
+)
+
+const (
+	// adaptiveWindow is the count of probes to evaluate the timeout ratio of a target
+	adaptiveWindow = 16
+	// backoffInitialRate is the rate to start backoff for unlimited targets
+	backoffInitialRate = 100
+	// backoffMinRate is the lowest rate for a target after backoff
+	backoffMinRate = 1
+)
+
+// targetLimiter limits probes to a single target, and backoff adaptively
+type targetLimiter struct {
+	*limiter
+	base     float64
+	probes   int
+	timeouts int
+}
+
+// prober dispatches probe units with rate limit, without busy looping
+type prober struct {
+	ping          *vnet.NPing
+	concurrency   int
+	timeout       time.Duration
+	bannerWait    time.Duration
+	retries       int
+	backoffThresh float64
+	targetRate    float64
+
+	global   *limiter
+	lock     sync.Mutex
+	queues   map[int][]*probeUnit
+	order    []int
+	next     int
+	limiters map[int]*targetLimiter
+
+	// workers started, grown on demand up to concurrency, only touched by the dispatcher
+	workers  int
+	notify   chan struct{}
+	work     chan *probeUnit
+	results  chan<- *touchResult
+	eligible func(id int) bool
+	priority func() int
+	valid    func(pu *probeUnit) bool
+}
+
+func newProber(ping *vnet.NPing, c *portconfig.PortsConfig, globalRate, targetRate float64, results chan<- *touchResult) *prober {
+	return &prober{
+		ping:          ping,
+		concurrency:   c.ProbeConcurrency,
+		timeout:       c.Timeout.Value,
+		bannerWait:    c.BannerWait.Value,
+		retries:       c.Retries,
+		backoffThresh: c.BackoffThresh,
+		targetRate:    targetRate,
+		global:        newLimiter(globalRate, 1),
+		queues:        make(map[int][]*probeUnit),
+		limiters:      make(map[int]*targetLimiter),
+		notify:        make(chan struct{}, 1),
+		work:          make(chan *probeUnit),
+		results:       results,
+		eligible:      func(int) bool { return true },
+		priority:      func() int { return -1 },
+		valid:         func(*probeUnit) bool { return true },
+	}
+}
+
+// start the dispatcher, workers are started lazily as probe units dispatched
+func (p *prober) start(ctx context.Context) {
+	go p.dispatch(ctx)
+}
+
+// wake the dispatcher up, e.g. after new probe units queued or eligible targets changed
+func (p *prober) wake() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *prober) enqueue(units ...*probeUnit) {
+	p.lock.Lock()
+	for _, pu := range units {
+		if _, ok := p.queues[pu.id]; !ok {
+			p.order = append(p.order, pu.id)
+		}
+		p.queues[pu.id] = append(p.queues[pu.id], pu)
+	}
+	p.lock.Unlock()
+	p.wake()
+}
+
+// clear all queued probe units
+func (p *prober) clear() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.queues = make(map[int][]*probeUnit)
+	p.order = nil
+	p.next = 0
+}
+
+func (p *prober) limiterOf(id int) *targetLimiter {
+	l, ok := p.limiters[id]
+	if !ok {
+		l = &targetLimiter{
+			limiter: newLimiter(p.targetRate, 1),
+			base:    p.targetRate,
+		}
+		p.limiters[id] = l
+	}
+	return l
+}
+
+// currentRate of target `id`, and whether the target is in backoff
+func (p *prober) currentRate(id int) (float64, bool) {
+	p.lock.Lock()
+	l := p.limiterOf(id)
+	p.lock.Unlock()
+	rate := l.currentRate()
+	return rate, rate > 0 && (l.base <= 0 || rate < l.base)
+}
+
+func (p *prober) pop(id int) *probeUnit {
+	q := p.queues[id]
+	pu := q[0]
+	if len(q) == 1 {
+		delete(p.queues, id)
+		for i, qid := range p.order {
+			if qid == id {
+				p.order = append(p.order[:i], p.order[i+1:]...)
+				break
+			}
+		}
+	} else {
+		p.queues[id] = q[1:]
+	}
+	return pu
+}
+
+// pick a probe unit of which target is allowed to probe at `now`,
+// prefer the priority target, and round robin among others.
+// If nothing to pick, returns how long to wait, zero means wait for notification
+func (p *prober) pick(now time.Time) (*probeUnit, time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var minWait time.Duration
+	try := func(id int) *probeUnit {
+		if _, ok := p.queues[id]; !ok || !p.eligible(id) {
+			return nil
+		}
+		if d := p.limiterOf(id).reserve(now); d > 0 {
+			if minWait == 0 || d < minWait {
+				minWait = d
+			}
+			return nil
+		}
+		return p.pop(id)
+	}
+
+	if pu := try(p.priority()); pu != nil {
+		return pu, 0
+	}
+	for i := 0; i < len(p.order); i++ {
+		idx := (p.next + i) % len(p.order)
+		if pu := try(p.order[idx]); pu != nil {
+			p.next = idx + 1
+			return pu, 0
+		}
+	}
+	return nil, minWait
+}
+
+func (p *prober) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// idle until notified, or `wait` elapsed if positive, returns false if ctx done
+func (p *prober) idle(ctx context.Context, wait time.Duration) bool {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-p.notify:
+	case <-timeout:
+	}
+	return true
+}
+
+func (p *prober) dispatch(ctx context.Context) {
+	for {
+		pu, wait := p.pick(time.Now())
+		if pu == nil {
+			if !p.idle(ctx, wait) {
+				return
+			}
+			continue
+		}
+		for d := p.global.reserve(time.Now()); d > 0; d = p.global.reserve(time.Now()) {
+			if !p.sleep(ctx, d) {
+				return
+			}
+		}
+		select {
+		case p.work <- pu:
+			continue
+		default:
+		}
+		if p.workers < p.concurrency {
+			p.workers++
+			go p.runWorker(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case p.work <- pu:
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+func (p *prober) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pu := <-p.work:
+			if !p.valid(pu) {
+				continue
+			}
+			connTime, banner, err := p.ping.Grab(pu.target, p.timeout, p.bannerWait)
+			timeout := err != nil && isTimeout(err)
+			p.feedback(pu.id, timeout)
+			if timeout && pu.attempt < p.retries {
+				pu.attempt++
+				p.enqueue(pu)
+				continue
+			}
+			p.results <- &touchResult{
+				id:         pu.id,
+				portID:     pu.portID,
+				generation: pu.generation,
+				connected:  err == nil,
+				timeout:    timeout,
+				connTime:   connTime,
+				banner:     banner,
+			}
+		}
+	}
+}
+
+// feedback the result of a probe to target `id`,
+// slow down if too many timeouts, and recover gradually
+func (p *prober) feedback(id int, timeout bool) {
+	if p.backoffThresh <= 0 {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	l := p.limiterOf(id)
+	l.probes++
+	if timeout {
+		l.timeouts++
+	}
+	if l.probes < adaptiveWindow {
+		return
+	}
+	ratio := float64(l.timeouts) / float64(l.probes)
+	l.probes, l.timeouts = 0, 0
+
+	now := time.Now()
+	rate := l.limiter.currentRate()
+	if ratio >= p.backoffThresh {
+		if rate <= 0 {
+			rate = backoffInitialRate
+		}
+		rate /= 2
+		if rate < backoffMinRate {
+			rate = backoffMinRate
+		}
+		l.setRate(now, rate)
+	} else if ratio < p.backoffThresh/2 && rate > 0 && (l.base <= 0 || rate < l.base) {
+		rate *= 2
+		if l.base > 0 && rate > l.base {
+			rate = l.base
+		} else if l.base <= 0 && rate >= backoffInitialRate {
+			rate = l.base
+		}
+		l.setRate(now, rate)
+	}
+}
@@ -7,42 +7,58 @@ import (
 	"time"
 
 	"github.com/yittg/ving/addons"
+	pc "github.com/yittg/ving/addons/port/config"
 	"github.com/yittg/ving/addons/port/types"
 	"github.com/yittg/ving/config"
+	"github.com/yittg/ving/event"
 	"github.com/yittg/ving/net"
 	"github.com/yittg/ving/net/protocol"
 	"github.com/yittg/ving/options"
 )
 
 type runtime struct {
-	targets    []*protocol.NetworkTarget
-	rawTargets []string
-	ping       *net.NPing
-	opt        *options.Option
-	active     bool
+	targets *addons.Targets
+	ping    *net.NPing
+	events  *event.Log
+	opt     *options.Option
+	active  bool
+	matrix  bool
 
 	selected    chan int
 	crtSelected int
 	resultChan  chan *touchResult
 	refreshChan chan int
+	exportChan  chan exportRequest
+	exported    bool
+	exportMsg   string
+	exportAt    time.Time
 
+	profiles    []string
+	crtProfile  int
+	customPorts []types.PortDesc
+	generation  *int32
 	targetPorts []types.PortDesc
 	targetDone  sync.Map
 	results     map[int][]touchResultWrapper
 
-	proberPool     sync.Map
-	proberPoolSize int
-	scheduling     *int32
+	// expectations of target ID, see `expectationFor`
+	expectations sync.Map
+
+	prober     *prober
+	scheduling *int32
 
 	ui         *ui
 	initUILock sync.Once
 }
 
 type touchResult struct {
-	id        int
-	portID    int
-	connected bool
-	connTime  time.Duration
+	id         int
+	portID     int
+	generation int32
+	connected  bool
+	timeout    bool
+	connTime   time.Duration
+	banner     string
 }
 
 type touchResultWrapper struct {
@@ -50,27 +66,22 @@ type touchResultWrapper struct {
 	res  *touchResult
 }
 
-type prober struct {
-	pipeMap sync.Map
-	running sync.Once
-}
-
 type probeUnit struct {
-	id     int
-	portID int
-	target *protocol.NetworkTarget
+	id         int
+	portID     int
+	generation int32
+	attempt    int
+	target     *protocol.NetworkTarget
 }
 
 func newPortAddOn() addons.AddOn {
-	portConfig := config.GetConfig().AddOns.Ports
 	return &runtime{
-		selected:       make(chan int, 1),
-		proberPool:     sync.Map{},
-		proberPoolSize: portConfig.ProbeConcurrency,
-		resultChan:     make(chan *touchResult, 1024),
-		targetDone:     sync.Map{},
-		results:        make(map[int][]touchResultWrapper),
-		refreshChan:    make(chan int, 1),
+		selected:    make(chan int, 1),
+		resultChan:  make(chan *touchResult, 1024),
+		targetDone:  sync.Map{},
+		results:     make(map[int][]touchResultWrapper),
+		refreshChan: make(chan int, 1),
+		exportChan:  make(chan exportRequest, 1),
 	}
 }
 
@@ -84,23 +95,56 @@ func (rt *runtime) Init(envoy *addons.Envoy) {
 	rt.targets = envoy.Targets
 	rt.opt = envoy.Opt
 	rt.ping = envoy.Ping
-	for _, t := range rt.targets {
-		rt.rawTargets = append(rt.rawTargets, t.Raw)
-	}
+	rt.events = envoy.Events
 
 	scheduling := int32(0)
 	rt.scheduling = &scheduling
+	generation := int32(0)
+	rt.generation = &generation
+	rt.profiles = getProfileNames()
 	if len(rt.opt.MorePorts) > 0 {
 		for _, p := range rt.opt.MorePorts {
-			rt.targetPorts = append(rt.targetPorts, types.PortDesc{Name: getNameOfPort(p), Port: p})
+			rt.customPorts = append(rt.customPorts, types.PortDesc{Name: getNameOfPort(p), Port: p})
 		}
+		rt.profiles = append([]string{customProfile}, rt.profiles...)
+		rt.opt.Ports = true
+	}
+	if rt.opt.PortsCheck && rt.opt.PortProfile == "" {
+		rt.opt.PortProfile = pc.PolicyProfile
+	}
+	if rt.opt.PortProfile != "" {
+		for i, name := range rt.profiles {
+			if name == rt.opt.PortProfile {
+				rt.crtProfile = i
+			}
+		}
+		rt.opt.Ports = true
+	}
+	rt.targetPorts = rt.portsOfProfile(rt.profiles[rt.crtProfile])
+
+	portConfig := config.GetConfig().AddOns.Ports
+	if portConfig.BannerWait.Value == 0 && rt.opt.PortsExport != "" {
+		portConfig.BannerWait.Value = exportBannerWait
+	}
+	rt.prober = newProber(rt.ping, &portConfig, rt.opt.PortsRate, rt.opt.PortsTargetRate, rt.resultChan)
+	rt.prober.eligible = func(id int) bool {
+		return rt.matrix || id == rt.currentSelected()
+	}
+	rt.prober.priority = rt.currentSelected
+	rt.prober.valid = func(pu *probeUnit) bool {
+		return pu.generation == atomic.LoadInt32(rt.generation)
+	}
+	if rt.opt.PortsMatrix || rt.opt.PortsCheck || (rt.opt.NoUI && rt.opt.Ports) {
+		rt.matrix = true
 		rt.opt.Ports = true
-	} else {
-		rt.targetPorts = getPredefinedPorts()
 	}
 }
 
 func (rt *runtime) Start(ctx context.Context) {
+	if rt.opt.NoUI && rt.opt.Ports {
+		rt.updateStatus(true)
+	}
+	rt.prober.start(ctx)
 	go rt.scanPorts(ctx)
 }
 
@@ -116,66 +160,50 @@ func (rt *runtime) scanPorts(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case rt.crtSelected = <-rt.selected:
-			if rt.crtSelected < 0 || rt.crtSelected >= len(rt.targets) {
-				host = nil
-				continue
-			}
-			host = rt.targets[rt.crtSelected]
+			rt.prober.wake()
+			host = rt.targets.Get(rt.crtSelected)
 		case id := <-rt.refreshChan:
 			rt.selected <- id
 		case <-ticker.C:
-			selected := rt.currentSelected()
-			if !rt.active || host == nil || !rt.checkNotBegin(selected) {
+			if !rt.active {
 				break
 			}
-			rt.targetDone.Store(selected, 0)
-			for i, port := range rt.targetPorts {
-				rt.probeTargetAsyc(selected, i, protocol.TCPTarget(host, port.Port))
+			if rt.matrix {
+				rt.scanAllTargets()
+				break
 			}
+			selected := rt.currentSelected()
+			if host == nil || !rt.checkNotBegin(selected) {
+				break
+			}
+			rt.scanTarget(selected, host)
 			host = nil
 		}
 	}
 }
 
-func (rt *runtime) probeTargetAsyc(idx, portID int, t *protocol.NetworkTarget) {
-	bucket := portID % rt.proberPoolSize
-	_p, existed := rt.proberPool.LoadOrStore(bucket, &prober{})
-	p := _p.(*prober)
-
-	chooseOrAllocatePipe := func(pipeMap *sync.Map, idx int) chan *probeUnit {
-		_pipe, ok := pipeMap.Load(idx)
-		if !ok {
-			_pipe = make(chan *probeUnit, 100)
-			pipeMap.Store(idx, _pipe)
-		}
-		return _pipe.(chan *probeUnit)
-	}
-
-	if !existed {
-		p.running.Do(func() {
-			go func(pipeMap *sync.Map) {
-				for {
-					select {
-					case pu := <-chooseOrAllocatePipe(pipeMap, rt.currentSelected()):
-						connTime, err := rt.ping.PingOnce(pu.target, time.Second)
-						rt.resultChan <- &touchResult{
-							id:        pu.id,
-							portID:    pu.portID,
-							connected: err == nil,
-							connTime:  connTime,
-						}
-					default:
-						time.Sleep(time.Millisecond * 10)
-					}
-				}
-			}(&p.pipeMap)
+func (rt *runtime) scanTarget(id int, host *protocol.NetworkTarget) {
+	generation := atomic.LoadInt32(rt.generation)
+	rt.targetDone.Store(id, 0)
+	units := make([]*probeUnit, 0, len(rt.targetPorts))
+	for i, port := range rt.targetPorts {
+		units = append(units, &probeUnit{
+			id:         id,
+			portID:     i,
+			generation: generation,
+			target:     protocol.TCPTarget(host, port.Port),
 		})
 	}
-	chooseOrAllocatePipe(&p.pipeMap, idx) <- &probeUnit{
-		id:     idx,
-		portID: portID,
-		target: t,
-	}
+	rt.prober.enqueue(units...)
+}
+
+func (rt *runtime) scanAllTargets() {
+	rt.targets.Each(func(id int, host *protocol.NetworkTarget) {
+		if host.Typ != protocol.IP || !rt.checkNotBegin(id) {
+			return
+		}
+		rt.scanTarget(id, host)
+	})
 }
 
 func (rt *runtime) resetTargetStatus(id int) {
@@ -185,9 +213,21 @@ func (rt *runtime) resetTargetStatus(id int) {
 
 	rt.results[id] = rt.prepareTouchResults()
 	rt.targetDone.Delete(id)
+	rt.exported = false
 	rt.refreshChan <- id
 }
 
+func (rt *runtime) resetAllTargetStatus() {
+	rt.targets.Each(func(id int, _ *protocol.NetworkTarget) {
+		if !rt.checkDone(id) {
+			return
+		}
+		rt.results[id] = rt.prepareTouchResults()
+		rt.targetDone.Delete(id)
+	})
+	rt.exported = false
+}
+
 func (rt *runtime) prepareTouchResults() []touchResultWrapper {
 	s := make([]touchResultWrapper, len(rt.targetPorts))
 	for i, port := range rt.targetPorts {
@@ -202,6 +242,9 @@ func (rt *runtime) doSchedule() {
 	for {
 		select {
 		case res := <-rt.resultChan:
+			if res.generation != atomic.LoadInt32(rt.generation) {
+				continue
+			}
 			s, ok := rt.results[res.id]
 			if !ok {
 				s = rt.prepareTouchResults()
@@ -224,12 +267,79 @@ func (rt *runtime) Schedule() {
 	}
 	defer atomic.StoreInt32(rt.scheduling, 0)
 	rt.doSchedule()
+	select {
+	case req := <-rt.exportChan:
+		rt.doExport(req.all)
+	default:
+		if rt.opt.PortsExport != "" && !rt.exported && rt.scanDone() {
+			rt.doExport(true)
+			rt.exported = true
+		}
+	}
+}
+
+// scanDone represents all targets scanned are done, all targets in matrix mode
+func (rt *runtime) scanDone() bool {
+	if rt.matrix {
+		done := true
+		rt.targets.Each(func(id int, t *protocol.NetworkTarget) {
+			if t.Typ == protocol.IP && !rt.checkDone(id) {
+				done = false
+			}
+		})
+		return done
+	}
+	if len(rt.results) == 0 {
+		return false
+	}
+	for id := range rt.results {
+		if !rt.checkDone(id) {
+			return false
+		}
+	}
+	return true
 }
 
 func (rt *runtime) updateStatus(active bool) {
 	rt.active = active
 }
 
+func (rt *runtime) portsOfProfile(name string) []types.PortDesc {
+	if name == customProfile {
+		return rt.customPorts
+	}
+	return getPortsOfProfile(name)
+}
+
+func (rt *runtime) currentProfile() string {
+	return rt.profiles[rt.crtProfile]
+}
+
+// nextProfile switches to next ports profile, drops all results and probes again
+func (rt *runtime) nextProfile() {
+	selected := rt.currentSelected()
+	begun := !rt.checkNotBegin(selected)
+
+	rt.crtProfile = (rt.crtProfile + 1) % len(rt.profiles)
+	rt.targetPorts = rt.portsOfProfile(rt.currentProfile())
+	atomic.AddInt32(rt.generation, 1)
+	rt.prober.clear()
+	rt.exported = false
+	rt.results = make(map[int][]touchResultWrapper)
+	rt.targetDone.Range(func(id, _ interface{}) bool {
+		rt.targetDone.Delete(id)
+		return true
+	})
+	if begun && !rt.matrix {
+		rt.refreshChan <- selected
+	}
+}
+
+func (rt *runtime) toggleMatrix() {
+	rt.matrix = !rt.matrix
+	rt.prober.wake()
+}
+
 func (rt *runtime) State() interface{} {
 	return rt.results
 }
@@ -0,0 +1,57 @@
+package port
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	servicesFile     = "/etc/services"
+	servicesLoadOnce sync.Once
+	servicesMap      map[int]string
+)
+
+// parseServices parses tcp services in the format of `/etc/services`
+func parseServices(r io.Reader) map[int]string {
+	services := make(map[int]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		portProto := strings.SplitN(fields[1], "/", 2)
+		if len(portProto) != 2 || portProto[1] != "tcp" {
+			continue
+		}
+		port, err := strconv.Atoi(portProto[0])
+		if err != nil || port <= 0 || port > 65535 {
+			continue
+		}
+		if _, ok := services[port]; !ok {
+			services[port] = fields[0]
+		}
+	}
+	return services
+}
+
+func lookupService(port int) (string, bool) {
+	servicesLoadOnce.Do(func() {
+		f, err := os.Open(servicesFile)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		servicesMap = parseServices(f)
+	})
+	name, ok := servicesMap[port]
+	return name, ok
+}
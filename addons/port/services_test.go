@@ -0,0 +1,28 @@
+package port
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseServices(t *testing.T) {
+	services := parseServices(strings.NewReader(`
+# Network services, Internet style
+tcpmux          1/tcp                           # TCP port service multiplexer
+ssh             22/tcp                          # SSH Remote Login Protocol
+domain          53/udp
+domain          53/tcp
+http            80/tcp          www             # WorldWideWeb HTTP
+www-alt         80/tcp
+bad             x/tcp
+`))
+	expected := map[int]string{1: "tcpmux", 22: "ssh", 53: "domain", 80: "http"}
+	if len(services) != len(expected) {
+		t.Fatalf("expect %d services, got %v", len(expected), services)
+	}
+	for port, name := range expected {
+		if services[port] != name {
+			t.Errorf("expect %s for port %d, got %q", name, port, services[port])
+		}
+	}
+}
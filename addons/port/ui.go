@@ -13,7 +13,8 @@ import (
 )
 
 const (
-	portsHeight = 8
+	portsHeight       = 8
+	exportMsgDuration = 5 * time.Second
 )
 
 var (
@@ -46,8 +47,9 @@ type ui struct {
 	par        *termui.Par
 	start      bool
 
-	view   viewEnum
-	filter filterEnum
+	view      viewEnum
+	filter    filterEnum
+	colOffset int
 
 	source *runtime
 }
@@ -86,6 +88,7 @@ func (pu *ui) Init() {
 	pu.par.BorderLeft = false
 	pu.par.BorderBottom = false
 	pu.par.BorderRight = false
+	pu.par.BorderLabelFg = termui.ColorYellow
 }
 
 // Activate this add-on
@@ -109,6 +112,10 @@ func (pu *ui) RespondEvents() []et.EventMeta {
 		{Keys: []string{"v"}, Description: "change view mode, name, port number, or both"},
 		{Keys: []string{"r"}, Description: "refresh and probe all ports again"},
 		{Keys: []string{"f"}, Description: "filter ports list, reached, unreached, or all"},
+		{Keys: []string{"m"}, Description: "toggle matrix view, probe all targets at once"},
+		{Keys: []string{"o"}, Description: "switch to next ports profile"},
+		{Keys: []string{"x"}, Description: "export results of the selected target, all targets in matrix mode"},
+		{Keys: []string{"X"}, Description: "export results of all targets"},
 	}
 }
 
@@ -121,6 +128,14 @@ func (pu *ui) HandleKeyEvent(ev termui.Event) {
 		pu.handleV()
 	case "r":
 		pu.handleR()
+	case "m":
+		pu.handleM()
+	case "o":
+		pu.handleO()
+	case "x":
+		pu.handleX(pu.source.matrix)
+	case "X":
+		pu.handleX(true)
 	}
 }
 
@@ -139,9 +154,58 @@ func (pu *ui) handleV() {
 }
 
 func (pu *ui) handleR() {
+	if pu.source.matrix {
+		pu.source.resetAllTargetStatus()
+		return
+	}
 	pu.source.resetTargetStatus(pu.TargetList.CurrentSelected())
 }
 
+func (pu *ui) handleM() {
+	pu.source.toggleMatrix()
+	pu.colOffset = 0
+	pu.par.Height = portsHeight
+}
+
+func (pu *ui) handleO() {
+	pu.source.nextProfile()
+	pu.colOffset = 0
+}
+
+func (pu *ui) handleX(all bool) {
+	select {
+	case pu.source.exportChan <- exportRequest{all: all}:
+	default:
+	}
+}
+
+// exportMessage returns message of the last export in a few seconds
+func (pu *ui) exportMessage(t time.Time) string {
+	if pu.source.exportMsg == "" || pu.source.exportAt.Add(exportMsgDuration).Before(t) {
+		return ""
+	}
+	return pu.source.exportMsg + " "
+}
+
+// Indicator see `addons.Indicator`, represents compliance with ports policies
+func (pu *ui) Indicator() string {
+	return pu.source.policyIndicator()
+}
+
+// OnLeft see `HorizontalDirectionAware`, scroll matrix columns
+func (pu *ui) OnLeft() {
+	if pu.colOffset > 0 {
+		pu.colOffset--
+	}
+}
+
+// OnRight see `HorizontalDirectionAware`, scroll matrix columns
+func (pu *ui) OnRight() {
+	if pu.colOffset < len(pu.source.targetPorts)-1 {
+		pu.colOffset++
+	}
+}
+
 // ActivateAfterStart see `addons.ActivateAfterStart`
 func (pu *ui) ActivateAfterStart() bool {
 	return pu.start
@@ -178,18 +242,27 @@ func (pu *ui) getPredicat() func(*touchResult) bool {
 	}
 }
 
+func (pu *ui) rotatingFlag(t time.Time) string {
+	return rotating[(t.UnixNano()/int64(time.Millisecond*100))%4]
+}
+
 // UpdateState of this add-on
 func (pu *ui) UpdateState(t time.Time, actives map[int]bool) {
-	pu.TargetList.UpdateState(pu.source.rawTargets, actives)
+	pu.TargetList.UpdateState(pu.source.targets.Titles(), actives)
+	pu.par.BorderLabel = common.MarkerLabel(pu.source.events)
 
 	st, ok := pu.source.State().(map[int][]touchResultWrapper)
 	if !ok {
 		return
 	}
+	if pu.source.matrix {
+		pu.updateMatrix(t, st, actives)
+		return
+	}
 	selected := pu.CurrentSelected()
 	thisSt, ok := st[selected]
 	if !ok {
-		pu.par.Text = "<enter> to start/continue"
+		pu.par.Text = fmt.Sprintf("[%s](fg-bold) <enter> to start/continue", pu.source.currentProfile())
 		return
 	}
 	predicate := pu.getPredicat()
@@ -211,6 +284,8 @@ func (pu *ui) UpdateState(t time.Time, actives map[int]bool) {
 		}
 		if trw.res == nil {
 			portsView += "[•](fg-grey)"
+		} else if pu.source.violates(selected, trw) {
+			portsView += "[✘](fg-magenta,fg-bold)"
 		} else if trw.res.connected {
 			portsView += "[•](fg-green)"
 		} else {
@@ -222,7 +297,7 @@ func (pu *ui) UpdateState(t time.Time, actives map[int]bool) {
 	if pu.source.checkDone(selected) {
 		summary = "[✔](fg-green)  "
 	} else if !pu.source.checkNotBegin(selected) {
-		summary = rotating[(t.UnixNano()/int64(time.Millisecond*100))%4] + "  "
+		summary = pu.rotatingFlag(t) + "  "
 	}
 	if pu.filter == reached {
 		summary += "[Reached #%d](fg-green) "
@@ -233,5 +308,10 @@ func (pu *ui) UpdateState(t time.Time, actives map[int]bool) {
 	} else {
 		summary += "Total #%d "
 	}
-	pu.par.Text = fmt.Sprintf(summary, matched) + portsView
+	summary = fmt.Sprintf(summary, matched)
+	if rate, backoff := pu.source.prober.currentRate(selected); backoff {
+		summary += fmt.Sprintf("[backoff %.0fpps](fg-yellow) ", rate)
+	}
+	summary += fmt.Sprintf("[%s](fg-bold) ", pu.source.currentProfile())
+	pu.par.Text = summary + pu.exportMessage(t) + portsView
 }
@@ -0,0 +1,126 @@
+package addons
+
+import (
+	"sync"
+
+	"github.com/yittg/ving/net/protocol"
+)
+
+// Targets holds main targets by ID, which may be added or removed at runtime,
+// it's safe for concurrent use
+type Targets struct {
+	lock    sync.RWMutex
+	targets []*protocol.NetworkTarget
+	removed map[int]bool
+}
+
+// NewTargets with initial targets, whose IDs are their indexes
+func NewTargets(targets []*protocol.NetworkTarget) *Targets {
+	return &Targets{
+		targets: append([]*protocol.NetworkTarget(nil), targets...),
+		removed: make(map[int]bool),
+	}
+}
+
+// Get target `id`, nil if not exists or removed
+func (ts *Targets) Get(id int) *protocol.NetworkTarget {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	if id < 0 || id >= len(ts.targets) || ts.removed[id] {
+		return nil
+	}
+	return ts.targets[id]
+}
+
+// Raw name of target `id`, which is kept after removed
+func (ts *Targets) Raw(id int) string {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	if id < 0 || id >= len(ts.targets) {
+		return ""
+	}
+	return ts.targets[id].Raw
+}
+
+// Title of target `id` to display, which is kept after removed
+func (ts *Targets) Title(id int) string {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	if id < 0 || id >= len(ts.targets) {
+		return ""
+	}
+	return ts.targets[id].Title()
+}
+
+// Titles represents titles of all targets to display, indexed by ID, including removed ones
+func (ts *Targets) Titles() []string {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	titles := make([]string, 0, len(ts.targets))
+	for _, t := range ts.targets {
+		titles = append(titles, t.Title())
+	}
+	return titles
+}
+
+// Each calls `f` with targets not removed in order of ID
+func (ts *Targets) Each(f func(id int, t *protocol.NetworkTarget)) {
+	ts.lock.RLock()
+	targets := make([]*protocol.NetworkTarget, len(ts.targets))
+	for id, t := range ts.targets {
+		if !ts.removed[id] {
+			targets[id] = t
+		}
+	}
+	ts.lock.RUnlock()
+	for id, t := range targets {
+		if t != nil {
+			f(id, t)
+		}
+	}
+}
+
+// Len represents count of targets not removed
+func (ts *Targets) Len() int {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	return len(ts.targets) - len(ts.removed)
+}
+
+// Add a target, returns its ID
+func (ts *Targets) Add(t *protocol.NetworkTarget) int {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	ts.targets = append(ts.targets, t)
+	return len(ts.targets) - 1
+}
+
+// Replace target `id` not removed with `t`, like its address changed
+func (ts *Targets) Replace(id int, t *protocol.NetworkTarget) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	if id >= 0 && id < len(ts.targets) && !ts.removed[id] {
+		ts.targets[id] = t
+	}
+}
+
+// Remove target `id`, whose ID is never reused
+func (ts *Targets) Remove(id int) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	if id >= 0 && id < len(ts.targets) {
+		ts.removed[id] = true
+	}
+}
+
+// Find the ID of target not removed by raw name or display name, -1 if not found
+func (ts *Targets) Find(name string) int {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	for id, t := range ts.targets {
+		if (t.Raw == name || t.Name == name) && !ts.removed[id] {
+			return id
+		}
+	}
+	return -1
+}
@@ -0,0 +1,35 @@
+package addons
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yittg/ving/net/protocol"
+)
+
+func TestTargets(t *testing.T) {
+	ts := NewTargets([]*protocol.NetworkTarget{{Raw: "a"}, {Raw: "b", Name: "B"}})
+	if id := ts.Add(&protocol.NetworkTarget{Raw: "c"}); id != 2 {
+		t.Errorf("expect id 2 of the added, but got %d", id)
+	}
+	if ts.Find("B") != 1 {
+		t.Errorf("expect b found by name")
+	}
+	ts.Remove(1)
+	if ts.Get(1) != nil || ts.Find("b") >= 0 || ts.Raw(1) != "b" || ts.Title(1) != "B" {
+		t.Errorf("expect b removed but name kept")
+	}
+	var ids []int
+	ts.Each(func(id int, _ *protocol.NetworkTarget) { ids = append(ids, id) })
+	if !reflect.DeepEqual(ids, []int{0, 2}) || ts.Len() != 2 {
+		t.Errorf("expect targets [0 2], but got %v", ids)
+	}
+	if !reflect.DeepEqual(ts.Titles(), []string{"a", "B", "c"}) {
+		t.Errorf("unexpected titles %v", ts.Titles())
+	}
+	ts.Replace(1, &protocol.NetworkTarget{Raw: "x"})
+	ts.Replace(2, &protocol.NetworkTarget{Raw: "c", Name: "C"})
+	if ts.Raw(1) != "b" || ts.Title(2) != "C" {
+		t.Errorf("expect only targets not removed replaced")
+	}
+}
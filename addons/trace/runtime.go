@@ -7,18 +7,18 @@ import (
 
 	"github.com/yittg/ving/addons"
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/event"
 	"github.com/yittg/ving/net"
-	"github.com/yittg/ving/net/protocol"
 	"github.com/yittg/ving/options"
 	"github.com/yittg/ving/types"
 )
 
 type runtime struct {
-	targets    []*protocol.NetworkTarget
-	rawTargets []string
-	ping       *net.NPing
-	opt        *options.Option
-	active     bool
+	targets *addons.Targets
+	ping    *net.NPing
+	opt     *options.Option
+	events  *event.Log
+	active  bool
 
 	traceSelected chan int
 	traceManually chan bool
@@ -48,10 +48,7 @@ func (tr *runtime) Init(envoy *addons.Envoy) {
 	tr.targets = envoy.Targets
 	tr.opt = envoy.Opt
 	tr.ping = envoy.Ping
-
-	for _, t := range tr.targets {
-		tr.rawTargets = append(tr.rawTargets, t.Raw)
-	}
+	tr.events = envoy.Events
 }
 
 func (tr *runtime) updateStatus(active bool) {
@@ -83,9 +80,12 @@ func (tr *runtime) traceTarget(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case selected := <-tr.traceSelected:
-			header = &types.RecordHeader{
-				ID:     selected,
-				Target: tr.targets[selected],
+			header = nil
+			if target := tr.targets.Get(selected); target != nil {
+				header = &types.RecordHeader{
+					ID:     selected,
+					Target: target,
+				}
 			}
 			ttl = 1
 		case manually = <-tr.traceManually:
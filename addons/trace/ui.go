@@ -87,7 +87,11 @@ func (tu *ui) Render() *termui.Row {
 
 // UpdateState see `AddOn`
 func (tu *ui) UpdateState(t time.Time, actives map[int]bool) {
-	tu.TargetList.UpdateState(tu.source.rawTargets, actives)
+	tu.TargetList.UpdateState(tu.source.targets.Titles(), actives)
+	if marker := common.MarkerLabel(tu.source.events); marker != "" {
+		tu.lc.BorderLabel = " ms" + marker
+		tu.lc.BorderLabelFg = termui.ColorYellow
+	}
 
 	st, ok := tu.source.State().(*St)
 	if !ok {
@@ -67,3 +67,9 @@ type DirectionAware interface {
 
 	HorizontalDirectionAware
 }
+
+// Indicator shows the overall state of the add-on in main board
+type Indicator interface {
+	// Indicator represents the overall state, empty if nothing to show
+	Indicator() string
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/BurntSushi/toml"
 	ports "github.com/yittg/ving/addons/port/config"
+	event "github.com/yittg/ving/event/config"
+	history "github.com/yittg/ving/history/config"
 	statistic "github.com/yittg/ving/statistic/config"
+	targets "github.com/yittg/ving/targets/config"
 	ui "github.com/yittg/ving/ui/config"
 )
 
@@ -17,6 +20,10 @@ type Config struct {
 	AddOns    AddOnConfig `toml:"add-ons"`
 	UI        ui.UIConfig
 	Statistic statistic.Config
+	Event     event.Config
+	History   history.Config
+	Targets   targets.Targets
+	Resolve   targets.Resolve
 }
 
 // AddOnConfig add on configs
@@ -42,6 +49,18 @@ func validate(c *Config) error {
 	if err := c.Statistic.Validate(); err != nil {
 		return err
 	}
+	if err := c.Event.Validate(); err != nil {
+		return err
+	}
+	if err := c.History.Validate(); err != nil {
+		return err
+	}
+	if err := c.Targets.Validate(c.Statistic.Window.Value); err != nil {
+		return err
+	}
+	if err := c.Resolve.Validate(); err != nil {
+		return err
+	}
 	return validateAddOnConfig(&c.AddOns)
 }
 
@@ -52,6 +71,9 @@ func init() {
 		},
 		UI:        ui.Default(),
 		Statistic: statistic.Default(),
+		Event:     event.Default(),
+		History:   history.Default(),
+		Resolve:   targets.DefaultResolve(),
 	}
 	for _, rcDir := range searchDir {
 		rcFile := rcDir + "/.ving.toml"
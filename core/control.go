@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// controlCommand handles arguments of a control command
+type controlCommand func(e *Engine, args string) error
+
+var controlCommands = map[string]controlCommand{
+	"mark": func(e *Engine, note string) error {
+		e.Mark(note)
+		return nil
+	},
+	"add": func(e *Engine, target string) error {
+		if target == "" {
+			return fmt.Errorf("no target")
+		}
+		return e.AddTarget(target)
+	},
+	"remove": onTarget(func(e *Engine, id int) {
+		e.RemoveTarget(id)
+	}),
+	"pause": orAll(func(e *Engine) {
+		e.PauseAll(true)
+	}, func(e *Engine, id int) {
+		e.PauseTarget(id, true)
+	}),
+	"resume": orAll(func(e *Engine) {
+		e.PauseAll(false)
+	}, func(e *Engine, id int) {
+		e.PauseTarget(id, false)
+	}),
+	"step": orAll(func(e *Engine) {
+		e.Step(-1)
+	}, func(e *Engine, id int) {
+		e.Step(id)
+	}),
+}
+
+// orAll applies `all` without arguments, or `f` on the target by name
+func orAll(all func(e *Engine), f func(e *Engine, id int)) controlCommand {
+	onOne := onTarget(f)
+	return func(e *Engine, target string) error {
+		if target == "" {
+			all(e)
+			return nil
+		}
+		return onOne(e, target)
+	}
+}
+
+// onTarget finds the target by name in arguments for `f`
+func onTarget(f func(e *Engine, id int)) controlCommand {
+	return func(e *Engine, target string) error {
+		id := e.targets.Find(target)
+		if id < 0 {
+			return fmt.Errorf("unknown target %s", target)
+		}
+		f(e, id)
+		return nil
+	}
+}
+
+// serveControl listens on the unix socket at path, each line is a command like `mark <note>`,
+// `add <target>`, `remove <target>`, `pause [target]`, `resume [target]` or `step [target]`,
+// all targets if no target given, replied with `ok` or `error <reason>`
+func (e *Engine) serveControl(ctx context.Context, path string) error {
+	_ = os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go e.handleControl(conn)
+		}
+	}()
+	return nil
+}
+
+func (e *Engine) handleControl(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, args := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			name, args = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		reply := "ok"
+		if cmd, ok := controlCommands[name]; !ok {
+			reply = fmt.Sprintf("error unknown command %s", name)
+		} else if err := cmd(e, args); err != nil {
+			reply = fmt.Sprintf("error %v", err)
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
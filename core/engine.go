@@ -2,12 +2,20 @@ package core
 
 import (
 	"context"
-	"sort"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/yittg/ving/addons"
 	"github.com/yittg/ving/common"
+	"github.com/yittg/ving/config"
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/event"
+	"github.com/yittg/ving/history"
 	"github.com/yittg/ving/net"
 	"github.com/yittg/ving/net/protocol"
 	"github.com/yittg/ving/options"
@@ -18,87 +26,160 @@ import (
 
 const (
 	defaultLoopPeriodic = time.Millisecond * 10
+	defaultSortPeriodic = time.Second * 5
+
+	// defaultMarkNote is the note of markers dropped without note
+	defaultMarkNote = "-"
 )
 
 // Engine of this utility
 type Engine struct {
 	opt *options.Option
 
-	targets []*protocol.NetworkTarget
+	targets *addons.Targets
+	// probes cancels pinging of target ID, and paused ones are not probing
+	probes map[int]context.CancelFunc
+	paused map[int]bool
+	// pauseAll pauses all targets, including ones added later
+	pauseAll bool
+	// held target ID paused individually, kept paused when all targets resumed
+	held map[int]bool
+	// stepping target ID, paused ones sending a round on demand
+	stepping map[int]bool
+	// settings of probing target ID
+	settings map[int]probeSetting
+	// ctx of probes, set when run
+	ctx context.Context
+	// resolutions of hostnames to re-resolve
+	resolutions []*resolution
+	// peers of target ID of the other family in dual-stack mode
+	peers map[int]int
 
 	ping *net.NPing
 
 	statistic map[int]*statistic.Detail
 	stSlice   []*statistic.Detail
+	sorter    *statistic.Sorter
+	resort    bool
 	records   chan types.Record
+	marks     chan string
+	ops       chan func()
+	events    *event.Log
+	history   *history.Store
 
 	console *ui.Console
 
 	addOns []addons.AddOn
+
+	finished chan struct{}
+	loopDone chan struct{}
 }
 
 // NewEngine new a engine instance
-func NewEngine(opt *options.Option, targets []string) (*Engine, error) {
-	networkTargets := make([]*protocol.NetworkTarget, 0, len(targets))
-	for _, t := range targets {
-		networkTargets = append(networkTargets, protocol.ResolveTarget(t))
+func NewEngine(opt *options.Option, args []string) (*Engine, error) {
+	specs, err := specsOf(opt, args)
+	if err != nil {
+		return nil, err
 	}
-	if opt.Gateway {
-		networkTargets = append(networkTargets, protocol.DiscoverGatewayTarget())
+	resolved := make([][]*protocol.NetworkTarget, 0, len(specs))
+	for _, spec := range specs {
+		resolved = append(resolved, resolveSpec(opt, spec))
 	}
-	if len(networkTargets) == 0 {
-		networkTargets = append(networkTargets, protocol.ResolveTarget("localhost"))
+	nPing := net.NewPing()
+
+	var store *history.Store
+	if historyConfig := config.GetConfig().History; historyConfig.Path != "" {
+		if store, err = history.Open(historyConfig.Path, historyConfig.Retention.Value); err != nil {
+			return nil, fmt.Errorf("open history store %s, %v", historyConfig.Path, err)
+		}
 	}
-	nTargets := len(networkTargets)
 
-	records := make(chan types.Record, nTargets)
-	nPing := net.NewPing()
+	e := &Engine{
+		opt:       opt,
+		targets:   addons.NewTargets(nil),
+		probes:    make(map[int]context.CancelFunc),
+		paused:    make(map[int]bool),
+		held:      make(map[int]bool),
+		stepping:  make(map[int]bool),
+		settings:  make(map[int]probeSetting),
+		peers:     make(map[int]int),
+		ping:      nPing,
+		statistic: make(map[int]*statistic.Detail),
+		sorter:    statistic.NewSorter(opt.SortStrategy, opt.SortDesc),
+		marks:     make(chan string, 16),
+		ops:       make(chan func(), 16),
+		events:    event.NewLog(),
+		history:   store,
+
+		addOns: addons.All,
+
+		finished: make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+	for i, spec := range specs {
+		e.addSpec(spec, resolved[i])
+	}
+	if opt.Gateway {
+		e.targets.Add(protocol.DiscoverGatewayTarget().Bind(opt.Interface))
+	}
+	if e.targets.Len() == 0 {
+		localhost := protocol.ResolveFamilyTarget("localhost", opt.Family)
+		localhost.Netns = opt.Netns
+		e.targets.Add(localhost.Bind(opt.Interface))
+	}
+	e.records = make(chan types.Record, e.targets.Len())
+	e.stSlice = make([]*statistic.Detail, 0, e.targets.Len())
 
-	addOns := addons.All
 	var addOnUIs []addons.UI
 	envoy := &addons.Envoy{
-		Targets: networkTargets,
-		Opt:     opt,
-		Ping:    nPing,
+		Targets:   e.targets,
+		Opt:       opt,
+		Ping:      nPing,
+		Events:    e.events,
+		Statistic: func(id int) *statistic.Detail { return e.statistic[id] },
+		Selected:  func() int { return e.console.Selected() },
 	}
-	for _, addOn := range addOns {
+	for _, addOn := range e.addOns {
 		addOn.Init(envoy)
 		addOnUIs = append(addOnUIs, addOn.GetUI())
 	}
-	return &Engine{
-		opt:       opt,
-		targets:   networkTargets,
-		ping:      nPing,
-		statistic: make(map[int]*statistic.Detail, nTargets),
-		stSlice:   make([]*statistic.Detail, 0, nTargets),
-		records:   records,
-
-		addOns:  addOns,
-		console: ui.NewConsole(addOnUIs),
-	}, nil
+	e.console = ui.NewConsole(opt, addOnUIs, e.sorter, e)
+	return e, nil
 }
 
-// Run the engine
-func (e *Engine) Run(ctx context.Context) {
+// Run the engine, returns the exit code
+func (e *Engine) Run(ctx context.Context) int {
 	c, cancel := context.WithCancel(ctx)
 	if err := e.ping.Start(ctx); err != nil {
 		common.ErrExit("start ping error", err, 2)
 	}
-	for idx, target := range e.targets {
-		header := types.RecordHeader{
-			ID:     idx,
-			Target: target,
+	e.ctx = c
+	e.targets.Each(func(id int, _ *protocol.NetworkTarget) {
+		e.startProbe(id)
+	})
+	if e.opt.Control != "" {
+		if err := e.serveControl(c, e.opt.Control); err != nil {
+			common.ErrExit("listen control socket error", err, 2)
 		}
-		go e.pingTarget(c, header)
+	}
+	go e.markOnSignal(c)
+	if e.opt.ResolveInterval > 0 {
+		go e.reresolve(c)
 	}
 	go e.loop(c)
 	for _, addOn := range e.addOns {
 		addOn.Start(c)
 	}
+	if e.opt.NoUI {
+		return e.runHeadless(cancel)
+	}
 	e.console.Run(cancel)
+	cancel()
+	<-e.loopDone
+	return 0
 }
 
-func (e *Engine) pingTarget(ctx context.Context, header types.RecordHeader) {
+func (e *Engine) pingTarget(ctx context.Context, header types.RecordHeader, setting probeSetting) {
 	if header.Target.Typ == protocol.Unknown {
 		e.records <- types.Record{
 			RecordHeader: header,
@@ -108,51 +189,144 @@ func (e *Engine) pingTarget(ctx context.Context, header types.RecordHeader) {
 		}
 		return
 	}
-	t := time.NewTicker(e.opt.Interval)
-
-	f := func() bool {
-		duration, err := e.ping.PingOnce(header.Target, e.opt.Timeout)
-		header.Rounds++
-		if err != nil {
-			_, isTimeout := err.(*errors.ErrTimeout)
-			e.records <- types.Record{
-				RecordHeader: header,
-				Successful:   false,
-				ErrMsg:       err.Error(),
-				IsFatal:      !isTimeout,
-			}
-			if !isTimeout {
-				return true
+	if e.pingRound(ctx, header, setting) {
+		return
+	}
+
+	// rounds are scheduled from the previous one like a ticker, which are dropped if falling behind
+	next := time.Now().Add(setting.wait())
+	t := time.NewTimer(time.Until(next))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if e.pingRound(ctx, header, setting) {
+				return
 			}
-		} else {
-			e.records <- types.Record{
-				RecordHeader: header,
-				Successful:   true,
-				Cost:         duration,
+			next = next.Add(setting.wait())
+			if now := time.Now(); next.Before(now) {
+				next = now
 			}
+			t.Reset(time.Until(next))
 		}
+	}
+}
+
+// pingRound sends pings of a burst concurrently, returns true if any error is fatal.
+// Records are dropped if canceled meanwhile, e.g. paused
+func (e *Engine) pingRound(ctx context.Context, header types.RecordHeader, setting probeSetting) bool {
+	records := burst(setting.burst, func(int) types.Record {
+		return e.pingOnce(header.Target, setting.timeout)
+	})
+	// stats of echo replies are accumulated, which are snapshot once after all pings of the burst done,
+	// so that they never go backwards among records of the burst
+	stats, hasStats := e.ping.EchoStats(header.Target)
+	if ctx.Err() != nil {
 		return false
 	}
+	for _, record := range records {
+		if hasStats {
+			record.Seq = stats.Seq
+			record.Duplicates = stats.Duplicates
+			record.Reordered = stats.Reordered
+			record.Late = stats.Late
+		}
+		record.RecordHeader = header
+		e.records <- record
+		if record.IsFatal {
+			return true
+		}
+	}
+	return false
+}
 
-	if f() {
-		return
+// burst of `n` records by `once` called concurrently with the index of each, in order of index rather than completion.
+// Echo requests of a burst are sent in order of sequences by the flow, without waiting for previous replies
+func burst(n int, once func(i int) types.Record) []types.Record {
+	records := make([]types.Record, n)
+	if n == 1 {
+		records[0] = once(0)
+		return records
+	}
+	wg := sync.WaitGroup{}
+	for i := range records {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			records[i] = once(i)
+		}(i)
+	}
+	wg.Wait()
+	return records
+}
+
+func (e *Engine) pingOnce(target *protocol.NetworkTarget, timeout time.Duration) types.Record {
+	duration, err := e.ping.PingOnce(target, timeout)
+	var record types.Record
+	if err != nil {
+		_, isTimeout := err.(*errors.ErrTimeout)
+		record.Successful = false
+		record.ErrMsg = err.Error()
+		record.IsFatal = !isTimeout
+	} else {
+		record.Successful = true
+		record.Cost = duration
 	}
+	return record
+}
+
+// Mark drops a marker with note, which is dealt in the loop, see `ui.Controller`
+func (e *Engine) Mark(note string) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		note = defaultMarkNote
+	}
+	select {
+	case e.marks <- note:
+	default:
+	}
+}
 
+// markOnSignal drops a marker on SIGUSR1
+func (e *Engine) markOnSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-t.C:
-			if f() {
-				return
+		case <-signals:
+			e.Mark("signal")
+		}
+	}
+}
+
+// dropMarker into event log, statistics and the history store
+func (e *Engine) dropMarker(t time.Time, note string) {
+	m := e.events.Mark(t, note)
+	for _, st := range e.statistic {
+		st.Mark()
+	}
+	if e.history != nil {
+		if err := e.history.Mark(t, note); err != nil {
+			e.closeHistory()
+			if e.opt.NoUI {
+				fmt.Fprintf(os.Stderr, "write history error, %v\n", err)
 			}
 		}
 	}
+	if e.opt.NoUI {
+		fmt.Println(m.Transition())
+	}
 }
 
+// retireRecords of statistic, not for paused targets so that the window is not drained
 func (e *Engine) retireRecords(t time.Time) {
 	for _, st := range e.statistic {
-		if st.Dead {
+		if st.Dead || st.Paused {
 			continue
 		}
 		st.RetireRecord(t)
@@ -163,25 +337,69 @@ func (e *Engine) getStatistic(header types.RecordHeader) *statistic.Detail {
 	target, ok := e.statistic[header.ID]
 	if !ok {
 		target = &statistic.Detail{
-			ID:    header.ID,
-			Title: header.Target.Raw,
-			Total: header.Rounds,
-			Cost:  make([]int, 1),
+			ID:     header.ID,
+			Title:  header.Target.Title(),
+			Group:  groupOf(header.Target),
+			Cost:   make([]int, 1),
+			Family: header.Target.Family(),
+		}
+		if e.paused[header.ID] {
+			target.Pause(time.Now())
 		}
 		e.statistic[header.ID] = target
+		e.linkPeer(target)
 		e.stSlice = append(e.stSlice, target)
+		e.resort = true
 	}
 	return target
 }
 
-func (e *Engine) sortedStatistic() {
-	sort.Sort(statistic.StSlice{
-		Details:      e.stSlice,
-		SortStrategy: statistic.Default,
-	})
+// observeEvents derives events from the record, prints them in non-interactive mode
+func (e *Engine) observeEvents(t time.Time, record types.Record, st *statistic.Detail) {
+	for _, ev := range e.events.Observe(t, record, st) {
+		if e.opt.NoUI {
+			fmt.Println(ev.Transition())
+		}
+	}
+}
+
+// recordHistory into the persistent store if enabled, which is disabled after failed
+func (e *Engine) recordHistory(t time.Time, record types.Record, st *statistic.Detail) {
+	if e.history == nil {
+		return
+	}
+	if err := e.history.Add(e.historyKey(record.RecordHeader), st.Title, t, record); err != nil {
+		e.closeHistory()
+		if e.opt.NoUI {
+			fmt.Fprintf(os.Stderr, "write history error, %v\n", err)
+		}
+	}
+}
+
+// historyKey of the target, its raw address, which is not changed by renaming.
+// Sub-targets of a hostname are keyed by the address, or the family in dual-stack mode
+func (e *Engine) historyKey(header types.RecordHeader) string {
+	target := header.Target
+	if _, ok := e.peers[header.ID]; ok {
+		return fmt.Sprintf("%s v%d", target.Raw, target.Family())
+	}
+	if e.opt.AllAddresses && target.Typ == protocol.IP && protocol.IsHostname(target.Raw) {
+		return fmt.Sprintf("%s %s", target.Raw, target.Address())
+	}
+	return target.Raw
+}
+
+func (e *Engine) closeHistory() {
+	if e.history == nil {
+		return
+	}
+	_ = e.history.Close()
+	e.history = nil
 }
 
 func (e *Engine) loop(ctx context.Context) {
+	defer close(e.loopDone)
+	defer e.closeHistory()
 	ticker := time.NewTicker(defaultLoopPeriodic)
 	lastSort := time.Now()
 	for {
@@ -196,15 +414,33 @@ func (e *Engine) loop(ctx context.Context) {
 				}
 				for {
 					select {
+					case note := <-e.marks:
+						e.dropMarker(t, note)
+					case op := <-e.ops:
+						op()
 					case res := <-e.records:
+						if e.targets.Get(res.ID) == nil {
+							// removed
+							break
+						}
 						st := e.getStatistic(res.RecordHeader)
+						res.Rounds = st.Total + 1
 						st.DealRecord(t, res)
+						if !res.IsFatal {
+							e.observeEvents(t, res, st)
+						}
+						e.recordHistory(t, res, st)
 					default:
-						if e.opt.Sort && lastSort.Add(5 * time.Second).Before(t) {
-							e.sortedStatistic()
+						if e.resort || e.sorter.Changed() || lastSort.Add(defaultSortPeriodic).Before(t) {
+							e.sorter.Sort(e.stSlice)
+							e.resort = false
 							lastSort = t
 						}
-						e.console.Render(t, e.stSlice)
+						if e.opt.NoUI {
+							e.checkFinished()
+						} else {
+							e.console.Render(t, e.stSlice)
+						}
 						return
 					}
 				}
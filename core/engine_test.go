@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestBurstInOrder(t *testing.T) {
+	start := time.Now()
+	records := burst(5, func(i int) types.Record {
+		// earlier pings replied later, none waits for previous replies
+		time.Sleep(time.Duration(5-i) * 20 * time.Millisecond)
+		return types.Record{Seq: i}
+	})
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("expect pings of a burst not waiting for previous replies, but took %v", elapsed)
+	}
+	for i, record := range records {
+		if record.Seq != i {
+			t.Fatalf("expect records in order of pings, but got %v", records)
+		}
+	}
+
+	records = burst(1, func(int) types.Record { return types.Record{Seq: 1} })
+	if len(records) != 1 || records[0].Seq != 1 {
+		t.Errorf("unexpected records of single ping, %v", records)
+	}
+}
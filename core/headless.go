@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yittg/ving/addons"
+)
+
+// exitCodeUnsatisfied represents some add-on reports unsatisfied result in non-interactive mode
+const exitCodeUnsatisfied = 3
+
+// checkFinished closes `finished` if all involved headless add-ons finished
+func (e *Engine) checkFinished() {
+	involved := false
+	for _, addOn := range e.addOns {
+		headless, ok := addOn.(addons.Headless)
+		if !ok || !headless.Involved() {
+			continue
+		}
+		if !headless.Finished() {
+			return
+		}
+		involved = true
+	}
+	if !involved {
+		return
+	}
+	select {
+	case <-e.finished:
+	default:
+		close(e.finished)
+	}
+}
+
+// runHeadless waits until interrupted, duration elapsed, or all involved add-ons finished,
+// then prints the report
+func (e *Engine) runHeadless(cancel context.CancelFunc) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	var deadline <-chan time.Time
+	if e.opt.Duration > 0 {
+		deadline = time.After(e.opt.Duration)
+	}
+	select {
+	case <-signals:
+	case <-deadline:
+	case <-e.finished:
+	}
+	cancel()
+	<-e.loopDone
+	return e.report(os.Stdout)
+}
+
+func (e *Engine) report(w io.Writer) int {
+	for _, st := range e.stSlice {
+		fmt.Fprintf(w, "%s: rounds #%d, errors #%d", st.Title, st.Total, st.ErrCount)
+		if st.Late > 0 || st.Duplicates > 0 || st.Reordered > 0 {
+			fmt.Fprintf(w, ", true loss #%d, late #%d, duplicates #%d, reordered #%d",
+				st.TrueLoss(), st.Late, st.Duplicates, st.Reordered)
+		}
+		if st.Dead {
+			if lastRecord := st.LastRecord(); lastRecord != nil {
+				fmt.Fprintf(w, ", dead: %v", lastRecord.View())
+			}
+		}
+		fmt.Fprintln(w)
+		if st.Dead {
+			continue
+		}
+		fmt.Fprintf(w, "    %-8s R factor %.1f, MOS %.2f\n", "quality", st.RFactor(), st.MOS())
+		if st.Peer != nil {
+			fmt.Fprintf(w, "    %-8s %s against v%d", "peer", st.AgainstPeer(), st.Peer.Family)
+			if ratio := st.PeerLatencyRatio(); ratio > 0 {
+				fmt.Fprintf(w, ", latency ratio %.2f", ratio)
+			}
+			fmt.Fprintln(w)
+		}
+		for _, ws := range st.Windows() {
+			fmt.Fprintf(w, "    %-8s records #%d, errors #%d, error rate %.2f%%",
+				ws.Name(), ws.Count, ws.ErrCount, ws.ErrRate()*100)
+			if cost := ws.AverageCost(); cost != math.MaxInt64 {
+				fmt.Fprintf(w, ", average latency %v", time.Duration(cost))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	if events := e.events.Events(); len(events) > 0 {
+		fmt.Fprintln(w, "events:")
+		now := time.Now()
+		for i := range events {
+			fmt.Fprintf(w, "    %s\n", events[i].Summary(now))
+		}
+	}
+	exitCode := 0
+	for _, addOn := range e.addOns {
+		headless, ok := addOn.(addons.Headless)
+		if !ok || !headless.Involved() {
+			continue
+		}
+		if !headless.Report(w) {
+			exitCode = exitCodeUnsatisfied
+		}
+	}
+	return exitCode
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/yittg/ving/event"
+	"github.com/yittg/ving/net/protocol"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/targets"
+)
+
+// resolution of a hostname, whose addresses are pinged as targets
+type resolution struct {
+	spec targets.Spec
+	// family to resolve, 4 or 6, or 0 for any
+	family int
+	// targets by address pinged
+	targets map[string]int
+}
+
+// unwatch target `id` removed, resolutions without targets are dropped
+func (e *Engine) unwatch(id int) {
+	kept := e.resolutions[:0]
+	for _, r := range e.resolutions {
+		for addr, rid := range r.targets {
+			if rid == id {
+				delete(r.targets, addr)
+			}
+		}
+		if len(r.targets) > 0 {
+			kept = append(kept, r)
+		}
+	}
+	e.resolutions = kept
+}
+
+// watched returns resolutions to re-resolve, nil if done
+func (e *Engine) watched(ctx context.Context) []*resolution {
+	snapshot := make(chan []*resolution, 1)
+	e.do(func() {
+		snapshot <- append([]*resolution(nil), e.resolutions...)
+	})
+	select {
+	case rs := <-snapshot:
+		return rs
+	case <-ctx.Done():
+		return nil
+	case <-e.loopDone:
+		return nil
+	}
+}
+
+// reresolve hostnames periodically, lookup failures are ignored and addresses kept
+func (e *Engine) reresolve(ctx context.Context) {
+	ticker := time.NewTicker(e.opt.ResolveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, r := range e.watched(ctx) {
+			ips, err := protocol.LookupIPs(r.spec.Address, r.family)
+			if err != nil || len(ips) == 0 {
+				continue
+			}
+			r := r
+			e.do(func() {
+				e.applyResolution(time.Now(), r, ips)
+			})
+		}
+	}
+}
+
+// applyResolution of addresses looked up at `t`, the address pinged is kept as long as it's still resolved
+func (e *Engine) applyResolution(t time.Time, r *resolution, ips []net.IP) {
+	if len(r.targets) == 0 {
+		// unwatched
+		return
+	}
+	looked := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		looked[ip.String()] = true
+	}
+	if e.opt.AllAddresses {
+		e.followAddresses(t, r, ips, looked)
+		return
+	}
+	for addr, id := range r.targets {
+		if !looked[addr] {
+			e.changeAddress(t, r, id, addr, ips[0])
+		}
+	}
+}
+
+// changeAddress of target `id` from `old` to `ip`, its probe is restarted if probing
+func (e *Engine) changeAddress(t time.Time, r *resolution, id int, old string, ip net.IP) {
+	target := e.targets.Get(id)
+	if target == nil {
+		return
+	}
+	changed := *target
+	changed.Target = &net.IPAddr{IP: ip}
+	changed.Source = nil
+	e.targets.Replace(id, changed.Bind(bindOf(e.opt, r.spec)))
+	delete(r.targets, old)
+	r.targets[ip.String()] = id
+	// abnormal replies are carried over before probing the new address
+	probing := e.probes[id] != nil
+	e.stopProbe(id)
+	e.ping.Succeed(target, e.targets.Get(id))
+	if probing {
+		e.startProbe(id)
+	}
+	if st, ok := e.statistic[id]; ok {
+		st.AddressChanged = t
+	}
+	e.noteAddress(t, id, changed.Title(), fmt.Sprintf("changed from %s to %s", old, ip))
+}
+
+// followAddresses of the hostname pinged as sub-targets, new addresses are added and gone ones removed
+func (e *Engine) followAddresses(t time.Time, r *resolution, ips []net.IP, looked map[string]bool) {
+	for _, ip := range ips {
+		if _, ok := r.targets[ip.String()]; ok {
+			continue
+		}
+		target := subTarget(r.spec, ip, ip.String())
+		target.Netns = netnsOf(e.opt, r.spec)
+		target = target.Bind(bindOf(e.opt, r.spec))
+		id := e.targets.Add(target)
+		e.configure(id, r.spec)
+		r.targets[ip.String()] = id
+		e.launch(id)
+		e.noteAddress(t, id, target.Title(), fmt.Sprintf("%s added", ip))
+	}
+	for addr, id := range r.targets {
+		if !looked[addr] {
+			e.noteAddress(t, id, e.targets.Title(id), fmt.Sprintf("%s gone", addr))
+			e.removeTarget(id)
+		}
+	}
+}
+
+// noteAddress changed of target `id` into the event log, prints it in non-interactive mode
+func (e *Engine) noteAddress(t time.Time, id int, title, detail string) {
+	ev := e.events.Note(t, event.AddressChanged, id, title, detail)
+	if e.opt.NoUI {
+		fmt.Println(ev.Transition())
+	}
+}
+
+// linkPeer of statistic `st` in dual-stack mode, if the peer's exists
+func (e *Engine) linkPeer(st *statistic.Detail) {
+	id, ok := e.peers[st.ID]
+	if !ok {
+		return
+	}
+	if peer, ok := e.statistic[id]; ok {
+		st.Peer, peer.Peer = peer, st
+	}
+}
+
+// unlinkPeer of target `id` removed
+func (e *Engine) unlinkPeer(id int) {
+	peerID, ok := e.peers[id]
+	if !ok {
+		return
+	}
+	delete(e.peers, id)
+	delete(e.peers, peerID)
+	if peer, ok := e.statistic[peerID]; ok {
+		peer.Peer = nil
+	}
+}
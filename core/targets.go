@@ -0,0 +1,448 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/yittg/ving/config"
+	"github.com/yittg/ving/net/protocol"
+	"github.com/yittg/ving/options"
+	"github.com/yittg/ving/targets"
+	targetconfig "github.com/yittg/ving/targets/config"
+	"github.com/yittg/ving/types"
+)
+
+// probeSetting of a target, which may override global options
+type probeSetting struct {
+	interval time.Duration
+	// jitter of interval as a fraction of it
+	jitter  float64
+	burst   int
+	timeout time.Duration
+}
+
+// wait before the next round, the interval with random jitter
+func (s probeSetting) wait() time.Duration {
+	if s.jitter == 0 {
+		return s.interval
+	}
+	return s.interval + time.Duration((rand.Float64()*2-1)*s.jitter*float64(s.interval))
+}
+
+func defaultSetting(opt *options.Option) probeSetting {
+	return probeSetting{interval: opt.Interval, jitter: opt.Jitter, burst: opt.Burst, timeout: opt.Timeout}
+}
+
+// settingOfSpec overrides global options by the spec
+func settingOfSpec(opt *options.Option, spec targets.Spec) probeSetting {
+	setting := defaultSetting(opt)
+	if spec.Interval > 0 {
+		setting.interval = spec.Interval
+	}
+	if spec.Jitter > 0 {
+		setting.jitter = spec.Jitter
+	}
+	if spec.Burst > 0 {
+		setting.burst = spec.Burst
+	}
+	if spec.Timeout > 0 {
+		setting.timeout = spec.Timeout
+	}
+	return setting
+}
+
+// checkSettings of specs, the interval with jitter should be shorter than the statistic window
+func checkSettings(opt *options.Option, specs []targets.Spec) error {
+	window := config.GetConfig().Statistic.Window.Value
+	for _, spec := range specs {
+		setting := settingOfSpec(opt, spec)
+		if targetconfig.MaxInterval(setting.interval, setting.jitter) >= window {
+			return fmt.Errorf("interval %v with jitter %v of target %s should be shorter than statistic window %v",
+				setting.interval, setting.jitter, spec.Address, window)
+		}
+	}
+	return nil
+}
+
+// specsOf targets from arguments, groups, and the targets file, with CIDRs and ranges expanded
+func specsOf(opt *options.Option, args []string) ([]targets.Spec, error) {
+	var specs []targets.Spec
+	if len(args) > 0 || len(opt.Groups) > 0 || opt.TargetsFile == "" {
+		var err error
+		if specs, err = targets.Select(args, opt.Groups, config.GetConfig().Targets); err != nil {
+			return nil, err
+		}
+	}
+	if opt.TargetsFile != "" {
+		fileSpecs, err := targets.ParseFile(opt.TargetsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read targets file %s, %v", opt.TargetsFile, err)
+		}
+		specs = append(specs, fileSpecs...)
+	}
+	specs, warnings := targets.Expand(specs)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return specs, checkSettings(opt, specs)
+}
+
+// resolveSpec as targets, every address of a hostname is a sub-target if pinging all addresses,
+// and a hostname of both families is resolved as a pair of IPv4 and IPv6 in dual-stack mode
+func resolveSpec(opt *options.Option, spec targets.Spec) []*protocol.NetworkTarget {
+	resolved := resolveAddresses(opt, spec)
+	for i, target := range resolved {
+		target.Netns = netnsOf(opt, spec)
+		resolved[i] = target.Bind(bindOf(opt, spec))
+	}
+	return resolved
+}
+
+func resolveAddresses(opt *options.Option, spec targets.Spec) []*protocol.NetworkTarget {
+	if spec.Type == targetconfig.TypeTCP {
+		return []*protocol.NetworkTarget{named(protocol.ResolveTCPTarget(spec.Address, opt.Family), spec)}
+	}
+	if opt.AllAddresses && protocol.IsHostname(spec.Address) {
+		if ips, err := protocol.LookupIPs(spec.Address, opt.Family); err == nil && len(ips) > 0 {
+			subs := make([]*protocol.NetworkTarget, 0, len(ips))
+			for _, ip := range ips {
+				subs = append(subs, subTarget(spec, ip, ip.String()))
+			}
+			return subs
+		}
+	}
+	if opt.DualStack && protocol.IsHostname(spec.Address) {
+		v4, v6 := protocol.ResolveFamilyTarget(spec.Address, 4), protocol.ResolveFamilyTarget(spec.Address, 6)
+		if v4.Typ == protocol.IP && v6.Typ == protocol.IP {
+			return []*protocol.NetworkTarget{
+				subTarget(spec, v4.Target.(*net.IPAddr).IP, "v4"),
+				subTarget(spec, v6.Target.(*net.IPAddr).IP, "v6"),
+			}
+		}
+	}
+	return []*protocol.NetworkTarget{named(protocol.ResolveFamilyTarget(spec.Address, opt.Family), spec)}
+}
+
+// bindOf the spec, the interface or source address to send probes from, empty for default
+func bindOf(opt *options.Option, spec targets.Spec) string {
+	if spec.Interface != "" {
+		return spec.Interface
+	}
+	return opt.Interface
+}
+
+// netnsOf the spec, the path of network namespace to send probes from, empty for the current one
+func netnsOf(opt *options.Option, spec targets.Spec) string {
+	if spec.Netns != "" {
+		return spec.Netns
+	}
+	return opt.Netns
+}
+
+// named target as its spec
+func named(target *protocol.NetworkTarget, spec targets.Spec) *protocol.NetworkTarget {
+	target.Name = spec.Name
+	target.Group = spec.Group
+	target.Tags = spec.Tags
+	return target
+}
+
+// subTarget of an address of the hostname, titled with label like "example.com 10.0.0.1",
+// and grouped by the hostname if no group
+func subTarget(spec targets.Spec, ip net.IP, label string) *protocol.NetworkTarget {
+	target := named(protocol.IPTarget(spec.Address, ip), spec)
+	title := spec.Name
+	if title == "" {
+		title = spec.Address
+	}
+	target.Name = fmt.Sprintf("%s %s", title, label)
+	if target.Group == "" {
+		target.Group = title
+	}
+	return target
+}
+
+// groupOf the target to lay out, the group or the first tag if none
+func groupOf(target *protocol.NetworkTarget) string {
+	if target.Group == "" && len(target.Tags) > 0 {
+		return target.Tags[0]
+	}
+	return target.Group
+}
+
+// addSpec adds targets resolved from the spec, and watches the hostname to re-resolve, returns IDs added.
+// Targets of both families resolved in dual-stack mode are linked as peers.
+func (e *Engine) addSpec(spec targets.Spec, resolved []*protocol.NetworkTarget) []int {
+	watch := e.opt.ResolveInterval > 0 && spec.Type != targetconfig.TypeTCP && protocol.IsHostname(spec.Address)
+	// resolutions by family, which is separated in dual-stack mode
+	resolutions := make(map[int]*resolution)
+	ids := make([]int, 0, len(resolved))
+	for _, target := range resolved {
+		id := e.targets.Add(target)
+		e.configure(id, spec)
+		ids = append(ids, id)
+		if !watch || target.Typ != protocol.IP {
+			continue
+		}
+		family := e.opt.Family
+		if e.opt.DualStack {
+			family = target.Family()
+		}
+		r, ok := resolutions[family]
+		if !ok {
+			r = &resolution{spec: spec, family: family, targets: make(map[string]int)}
+			resolutions[family] = r
+			e.resolutions = append(e.resolutions, r)
+		}
+		r.targets[target.Address()] = id
+	}
+	if e.opt.DualStack && len(ids) == 2 && resolved[0].Typ == protocol.IP && resolved[1].Typ == protocol.IP &&
+		resolved[0].Family() != resolved[1].Family() {
+		e.peers[ids[0]], e.peers[ids[1]] = ids[1], ids[0]
+	}
+	return ids
+}
+
+// configure target `id` by its spec
+func (e *Engine) configure(id int, spec targets.Spec) {
+	e.settings[id] = settingOfSpec(e.opt, spec)
+	e.events.Override(id, spec.DownAfter, spec.DegradedLatency)
+}
+
+// settingOf target `id`, global options if not configured
+func (e *Engine) settingOf(id int) probeSetting {
+	if setting, ok := e.settings[id]; ok {
+		return setting
+	}
+	return defaultSetting(e.opt)
+}
+
+// do the operation in the loop, dropped if the loop is done
+func (e *Engine) do(op func()) {
+	select {
+	case e.ops <- op:
+	case <-e.loopDone:
+	}
+}
+
+// startProbe starts pinging target `id`
+func (e *Engine) startProbe(id int) {
+	target := e.targets.Get(id)
+	if target == nil || e.probes[id] != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(e.ctx)
+	e.probes[id] = cancel
+	go e.pingTarget(ctx, types.RecordHeader{ID: id, Target: target}, e.settingOf(id))
+}
+
+// launch probing of target `id` added, which is paused if all targets are paused
+func (e *Engine) launch(id int) {
+	if e.pauseAll && e.targets.Get(id).Typ != protocol.Unknown {
+		e.setPaused(id, true)
+		return
+	}
+	e.startProbe(id)
+}
+
+// stopProbe stops pinging target `id`
+func (e *Engine) stopProbe(id int) {
+	if cancel := e.probes[id]; cancel != nil {
+		cancel()
+		delete(e.probes, id)
+	}
+}
+
+// AddTarget starts pinging a new target, CIDRs and ranges are expanded,
+// and `@group` adds targets in the group defined, see `ui.Controller`.
+// Targets are resolved in background, so only errors of the target itself are returned
+func (e *Engine) AddTarget(raw string) error {
+	specs, err := targets.Select([]string{raw}, nil, config.GetConfig().Targets)
+	if err != nil {
+		return err
+	}
+	specs, warnings := targets.Expand(specs)
+	if err := checkSettings(e.opt, specs); err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		e.warn(warning)
+	}
+	go func() {
+		resolved := make([][]*protocol.NetworkTarget, 0, len(specs))
+		for _, spec := range specs {
+			resolved = append(resolved, resolveSpec(e.opt, spec))
+		}
+		e.do(func() {
+			for i, spec := range specs {
+				for _, id := range e.addSpec(spec, resolved[i]) {
+					e.launch(id)
+				}
+			}
+		})
+	}()
+	return nil
+}
+
+// warn at runtime, printed in non-interactive mode, or shown in the status bar
+func (e *Engine) warn(msg string) {
+	if e.opt.NoUI {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+		return
+	}
+	e.console.Notify(fmt.Sprintf("[warning: %s](fg-yellow)", msg))
+}
+
+// RemoveTarget stops pinging target `id` and drops its statistic, see `ui.Controller`
+func (e *Engine) RemoveTarget(id int) {
+	e.do(func() {
+		e.removeTarget(id)
+	})
+}
+
+func (e *Engine) removeTarget(id int) {
+	target := e.targets.Get(id)
+	if target == nil {
+		return
+	}
+	e.stopProbe(id)
+	e.targets.Remove(id)
+	e.ping.Forget(target)
+	e.unwatch(id)
+	for _, ev := range e.events.Forget(time.Now(), id) {
+		if e.opt.NoUI {
+			fmt.Println(ev.Transition())
+		}
+	}
+	delete(e.paused, id)
+	delete(e.held, id)
+	delete(e.stepping, id)
+	delete(e.settings, id)
+	e.unlinkPeer(id)
+	if e.sorter.IsPinned(id) {
+		e.sorter.TogglePin(id)
+	}
+	if _, ok := e.statistic[id]; !ok {
+		return
+	}
+	delete(e.statistic, id)
+	for i, st := range e.stSlice {
+		if st.ID == id {
+			e.stSlice = append(e.stSlice[:i], e.stSlice[i+1:]...)
+			break
+		}
+	}
+}
+
+// TogglePause pauses pinging target `id`, or resumes it, see `ui.Controller`
+func (e *Engine) TogglePause(id int) {
+	e.do(func() {
+		e.hold(id, !e.paused[id])
+	})
+}
+
+// PauseTarget pauses pinging target `id` or resumes it
+func (e *Engine) PauseTarget(id int, paused bool) {
+	e.do(func() {
+		e.hold(id, paused)
+	})
+}
+
+// hold target `id` paused individually, which is kept paused when all targets resumed, or resume it
+func (e *Engine) hold(id int, paused bool) {
+	if paused {
+		e.held[id] = true
+	} else {
+		delete(e.held, id)
+	}
+	e.setPaused(id, paused)
+}
+
+func (e *Engine) setPaused(id int, paused bool) {
+	if e.targets.Get(id) == nil {
+		return
+	}
+	if st, ok := e.statistic[id]; ok {
+		if st.Dead {
+			return
+		}
+		if paused {
+			st.Pause(time.Now())
+		} else {
+			st.Resume(time.Now())
+		}
+	}
+	if paused {
+		e.paused[id] = true
+		e.stopProbe(id)
+	} else {
+		delete(e.paused, id)
+		// resumed after the stepping round if any, see `step`
+		if !e.stepping[id] {
+			e.startProbe(id)
+		}
+	}
+}
+
+// TogglePauseAll pauses pinging all targets, or resumes them, see `ui.Controller`
+func (e *Engine) TogglePauseAll() {
+	e.do(func() {
+		e.setPauseAll(!e.pauseAll)
+	})
+}
+
+// PauseAll pauses pinging all targets or resumes them
+func (e *Engine) PauseAll(paused bool) {
+	e.do(func() {
+		e.setPauseAll(paused)
+	})
+}
+
+// setPauseAll pauses all targets, or resumes them except those held paused individually
+func (e *Engine) setPauseAll(paused bool) {
+	e.pauseAll = paused
+	e.targets.Each(func(id int, target *protocol.NetworkTarget) {
+		if target.Typ != protocol.Unknown && (paused || !e.held[id]) {
+			e.setPaused(id, paused)
+		}
+	})
+}
+
+// Step sends a round to paused target `id`, or all paused targets if `id` is negative, see `ui.Controller`
+func (e *Engine) Step(id int) {
+	e.do(func() {
+		if id >= 0 {
+			e.step(id)
+			return
+		}
+		for paused := range e.paused {
+			e.step(paused)
+		}
+	})
+}
+
+// step sends a round to target `id` if paused and not stepping
+func (e *Engine) step(id int) {
+	target := e.targets.Get(id)
+	if target == nil || target.Typ == protocol.Unknown || !e.paused[id] || e.stepping[id] {
+		return
+	}
+	if st, ok := e.statistic[id]; ok && st.Dead {
+		return
+	}
+	e.stepping[id] = true
+	header, setting := types.RecordHeader{ID: id, Target: target}, e.settingOf(id)
+	go func() {
+		e.pingRound(e.ctx, header, setting)
+		e.do(func() {
+			delete(e.stepping, id)
+			if !e.paused[id] {
+				e.startProbe(id)
+			}
+		})
+	}()
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/config"
+	"github.com/yittg/ving/options"
+	"github.com/yittg/ving/targets"
+)
+
+func TestProbeSettingWait(t *testing.T) {
+	setting := probeSetting{interval: time.Second}
+	if d := setting.wait(); d != time.Second {
+		t.Errorf("expect interval without jitter, but got %v", d)
+	}
+	setting.jitter = 0.2
+	for i := 0; i < 1000; i++ {
+		if d := setting.wait(); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("expect wait within ±20%% of interval, but got %v", d)
+		}
+	}
+}
+
+func TestSettingOfSpec(t *testing.T) {
+	opt := &options.Option{Interval: time.Second, Jitter: 0.1, Burst: 1, Timeout: time.Second}
+	setting := settingOfSpec(opt, targets.Spec{Interval: 200 * time.Millisecond, Burst: 5})
+	expected := probeSetting{interval: 200 * time.Millisecond, jitter: 0.1, burst: 5, timeout: time.Second}
+	if setting != expected {
+		t.Errorf("expect setting %+v, but got %+v", expected, setting)
+	}
+}
+
+func TestCheckSettings(t *testing.T) {
+	window := config.GetConfig().Statistic.Window.Value
+	opt := &options.Option{Interval: window / 2, Burst: 1, Timeout: time.Second}
+	if err := checkSettings(opt, []targets.Spec{{Address: "10.0.0.1"}}); err != nil {
+		t.Errorf("expect interval shorter than window valid, but got %v", err)
+	}
+	if err := checkSettings(opt, []targets.Spec{{Address: "10.0.0.1", Jitter: 0.5}}); err != nil {
+		t.Errorf("expect interval with jitter shorter than window valid, but got %v", err)
+	}
+	if err := checkSettings(opt, []targets.Spec{{Address: "10.0.0.1"}, {Address: "10.0.0.2", Jitter: 0.99}}); err != nil {
+		t.Errorf("expect interval with jitter shorter than window valid, but got %v", err)
+	}
+	if err := checkSettings(opt, []targets.Spec{{Address: "10.0.0.1"}, {Address: "10.0.0.2", Interval: window}}); err == nil {
+		t.Error("expect error of interval as long as window")
+	}
+	opt.Jitter = 0.5
+	if err := checkSettings(opt, []targets.Spec{{Address: "10.0.0.1", Interval: window * 3 / 4}}); err == nil {
+		t.Error("expect error of interval with jitter longer than window")
+	}
+}
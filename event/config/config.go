@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	c "github.com/yittg/ving/config/encoding"
+	"github.com/yittg/ving/errors"
+)
+
+// Config of event detection
+type Config struct {
+	// DownAfter consecutive losses, the target is considered down
+	DownAfter int `toml:"down-after"`
+
+	// DegradedLatency is the threshold of average latency in window, 0 means disabled
+	DegradedLatency c.Duration `toml:"degraded-latency"`
+
+	// FlapCount of down and recovered transitions in FlapWindow, the target is considered flapping
+	FlapCount  int        `toml:"flap-count"`
+	FlapWindow c.Duration `toml:"flap-window"`
+
+	// MaxEvents kept in the event log
+	MaxEvents int `toml:"max-events"`
+}
+
+// Validate the event config
+func (c *Config) Validate() error {
+	if c.DownAfter <= 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("consecutive losses to be down should be positive, (down-after=%d)", c.DownAfter),
+		}
+	}
+	if c.DegradedLatency.Value < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("degraded latency should not be negative, (degraded-latency=%v)", c.DegradedLatency.Value),
+		}
+	}
+	if c.FlapCount < 2 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("transitions to be flapping should be at least 2, (flap-count=%d)", c.FlapCount),
+		}
+	}
+	if c.FlapWindow.Value < time.Second {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("invalid flap window, should longer than 1s, (flap-window=%v)", c.FlapWindow.Value),
+		}
+	}
+	if c.MaxEvents <= 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("max events should be positive, (max-events=%d)", c.MaxEvents),
+		}
+	}
+	return nil
+}
+
+// Default config of event detection
+func Default() Config {
+	return Config{
+		DownAfter: 3,
+		DegradedLatency: c.Duration{
+			Value: 300 * time.Millisecond,
+		},
+		FlapCount: 4,
+		FlapWindow: c.Duration{
+			Value: time.Minute,
+		},
+		MaxEvents: 1000,
+	}
+}
@@ -0,0 +1,146 @@
+package event
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yittg/ving/event/config"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/types"
+)
+
+// detector derives events of a single target from its records
+type detector struct {
+	cfg *config.Config
+
+	id     int
+	target string
+
+	losses      int
+	firstLossAt time.Time
+	transitions []time.Time
+
+	down     *Event
+	degraded *Event
+	flapping *Event
+	anomaly  *Event
+}
+
+func newDetector(cfg *config.Config, id int, target string) *detector {
+	return &detector{
+		cfg:    cfg,
+		id:     id,
+		target: target,
+	}
+}
+
+func (d *detector) newEvent(kind Kind, start time.Time, detail string) *Event {
+	return &Event{
+		ID:     d.id,
+		Target: d.target,
+		Kind:   kind,
+		Start:  start,
+		Detail: detail,
+	}
+}
+
+// end all ongoing events at `t`, e.g. when the target is removed
+func (d *detector) end(t time.Time) (changed []*Event) {
+	for _, e := range []**Event{&d.down, &d.degraded, &d.flapping, &d.anomaly} {
+		if *e != nil {
+			(*e).End = t
+			changed = append(changed, *e)
+			*e = nil
+		}
+	}
+	return
+}
+
+// observe the record dealt at `t`, returns events started or ended
+func (d *detector) observe(t time.Time, record types.Record, st *statistic.Detail) (changed []*Event) {
+	if record.Successful {
+		d.losses = 0
+		if d.down != nil {
+			d.down.End = t
+			changed = append(changed, d.down)
+			d.down = nil
+			d.transitions = append(d.transitions, t)
+		}
+	} else {
+		if d.losses == 0 {
+			d.firstLossAt = t
+		}
+		d.losses++
+		if d.down == nil && d.losses >= d.cfg.DownAfter {
+			d.down = d.newEvent(Down, d.firstLossAt, fmt.Sprintf("%d consecutive losses, %s", d.losses, record.ErrMsg))
+			changed = append(changed, d.down)
+			d.transitions = append(d.transitions, t)
+		}
+	}
+	changed = append(changed, d.observeDegraded(t, st)...)
+	changed = append(changed, d.observeAnomaly(t, st)...)
+	return append(changed, d.observeFlapping(t)...)
+}
+
+func (d *detector) observeDegraded(t time.Time, st *statistic.Detail) []*Event {
+	thresh := d.cfg.DegradedLatency.Value
+	if thresh <= 0 {
+		return nil
+	}
+	cost := st.LastAverageCost()
+	if cost == math.MaxInt64 {
+		// no successful records to judge
+		return nil
+	}
+	if d.degraded == nil && time.Duration(cost) > thresh {
+		d.degraded = d.newEvent(Degraded, t,
+			fmt.Sprintf("average latency %v above %v", time.Duration(cost), thresh))
+		return []*Event{d.degraded}
+	}
+	if d.degraded != nil && time.Duration(cost) <= thresh {
+		d.degraded.End = t
+		ended := d.degraded
+		d.degraded = nil
+		return []*Event{ended}
+	}
+	return nil
+}
+
+func (d *detector) observeAnomaly(t time.Time, st *statistic.Detail) []*Event {
+	anomalous := st.Anomalous()
+	if d.anomaly == nil && anomalous {
+		median, score := st.WindowMedianCost()
+		baseline, deviation, _ := st.Baseline()
+		d.anomaly = d.newEvent(Anomaly, t, fmt.Sprintf("median latency %v, %.1f deviations above baseline %v±%v",
+			median, score, baseline.Truncate(time.Microsecond), deviation.Truncate(time.Microsecond)))
+		return []*Event{d.anomaly}
+	}
+	if d.anomaly != nil && !anomalous {
+		d.anomaly.End = t
+		ended := d.anomaly
+		d.anomaly = nil
+		return []*Event{ended}
+	}
+	return nil
+}
+
+func (d *detector) observeFlapping(t time.Time) []*Event {
+	window := d.cfg.FlapWindow.Value
+	i := 0
+	for ; i < len(d.transitions) && d.transitions[i].Add(window).Before(t); i++ {
+	}
+	d.transitions = d.transitions[i:]
+	if d.flapping == nil && len(d.transitions) >= d.cfg.FlapCount {
+		d.flapping = d.newEvent(Flapping, d.transitions[0],
+			fmt.Sprintf("%d transitions in %v", len(d.transitions), window))
+		return []*Event{d.flapping}
+	}
+	if d.flapping != nil && len(d.transitions) == 0 {
+		d.flapping.End = t
+		ended := d.flapping
+		d.flapping = nil
+		return []*Event{ended}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/event/config"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/types"
+)
+
+func TestDetectorDownAndFlapping(t *testing.T) {
+	cfg := config.Default()
+	cfg.DownAfter = 2
+	cfg.FlapCount = 4
+	d := newDetector(&cfg, 0, "target")
+	st := &statistic.Detail{Cost: make([]int, 1)}
+
+	start := time.Now()
+	var events []*Event
+	var kinds []string
+	// down twice in a short time, which is flapping
+	pattern := []bool{true, false, false, true, false, false, true}
+	for i, successful := range pattern {
+		now := start.Add(time.Duration(i) * time.Second)
+		record := types.Record{Successful: successful, Cost: time.Millisecond}
+		st.DealRecord(now, record)
+		for _, e := range d.observe(now, record, st) {
+			state := "start"
+			if !e.Ongoing() {
+				state = "end"
+			}
+			events = append(events, e)
+			kinds = append(kinds, e.Kind.String()+":"+state)
+		}
+	}
+
+	expected := []string{"down:start", "down:end", "down:start", "down:end", "flapping:start"}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expect events %v, but got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("expect events %v, but got %v", expected, kinds)
+		}
+	}
+	if down := events[1]; down.Start != start.Add(time.Second) || down.Duration(time.Now()) != 2*time.Second {
+		t.Errorf("unexpected down event, start at %v, lasted %v", down.Start, down.Duration(time.Now()))
+	}
+}
+
+func TestDetectorDegraded(t *testing.T) {
+	cfg := config.Default()
+	d := newDetector(&cfg, 0, "target")
+	st := &statistic.Detail{Cost: make([]int, 1)}
+
+	now := time.Now()
+	record := types.Record{Successful: true, Cost: 2 * cfg.DegradedLatency.Value}
+	st.DealRecord(now, record)
+	if events := d.observe(now, record, st); len(events) != 1 || events[0].Kind != Degraded || !events[0].Ongoing() {
+		t.Fatalf("expect degraded event started, but got %v", events)
+	}
+
+	now = now.Add(time.Hour)
+	st.RetireRecord(now)
+	record = types.Record{Successful: true, Cost: time.Millisecond}
+	st.DealRecord(now, record)
+	if events := d.observe(now, record, st); len(events) != 1 || events[0].Kind != Degraded || events[0].Ongoing() {
+		t.Fatalf("expect degraded event ended, but got %v", events)
+	}
+}
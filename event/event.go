@@ -0,0 +1,106 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind of event
+type Kind int
+
+// Provided kinds of events
+const (
+	// Down represents consecutive losses, ended when recovered
+	Down Kind = iota
+	// Degraded represents average latency in window above the threshold
+	Degraded
+	// Flapping represents frequent down and recovered transitions
+	Flapping
+	// Anomaly represents median latency in window deviates significantly from the learned baseline
+	Anomaly
+	// Marker represents an annotation dropped by user, with a note as detail
+	Marker
+	// AddressChanged represents the address of a hostname target changed after re-resolved,
+	// with old and new addresses as detail
+	AddressChanged
+)
+
+var kindNames = []string{
+	Down:           "down",
+	Degraded:       "degraded",
+	Flapping:       "flapping",
+	Anomaly:        "anomaly",
+	Marker:         "marker",
+	AddressChanged: "address",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// Event happened to a target, with start and end time
+type Event struct {
+	ID     int
+	Target string
+	Kind   Kind
+	Start  time.Time
+	// End is zero if the event is ongoing
+	End    time.Time
+	Detail string
+}
+
+// Label of marker, like "10:01:02 switched to backup link"
+func (e *Event) Label() string {
+	return fmt.Sprintf("%s %s", e.Start.Format("15:04:05"), e.Detail)
+}
+
+// Instant represents whether the event happened at a moment, which has no duration
+func (e *Event) Instant() bool {
+	return e.Kind == Marker || e.Kind == AddressChanged
+}
+
+// Ongoing represents whether the event is not ended
+func (e *Event) Ongoing() bool {
+	return e.End.IsZero()
+}
+
+// Duration of the event, till `now` if ongoing
+func (e *Event) Duration(now time.Time) time.Duration {
+	if e.Ongoing() {
+		return now.Sub(e.Start)
+	}
+	return e.End.Sub(e.Start)
+}
+
+// Transition describes the latest change of the event
+func (e *Event) Transition() string {
+	if e.Kind == Marker {
+		return fmt.Sprintf("%s %s, %s", e.Start.Format(timeLayout), e.Kind, e.Detail)
+	}
+	if e.Ongoing() || e.Instant() {
+		return fmt.Sprintf("%s %s %s, %s", e.Start.Format(timeLayout), e.Target, e.Kind, e.Detail)
+	}
+	ended := "ended"
+	if e.Kind == Down {
+		ended = "recovered"
+	}
+	return fmt.Sprintf("%s %s %s, %s after %v", e.End.Format(timeLayout), e.Target, ended, e.Kind,
+		e.Duration(e.End).Truncate(time.Second))
+}
+
+// Summary of the event, with start, end time and duration
+func (e *Event) Summary(now time.Time) string {
+	if e.Kind == Marker {
+		return fmt.Sprintf("%s %-8s %s", e.Start.Format(timeLayout), e.Kind, e.Detail)
+	}
+	if e.Instant() {
+		return fmt.Sprintf("%s %-8s %s %s", e.Start.Format(timeLayout), e.Kind, e.Target, e.Detail)
+	}
+	span := fmt.Sprintf("ongoing for %v", e.Duration(now).Truncate(time.Second))
+	if !e.Ongoing() {
+		span = fmt.Sprintf("till %s, lasted %v", e.End.Format(timeLayout), e.Duration(now).Truncate(time.Second))
+	}
+	return fmt.Sprintf("%s %-8s %s %s, %s", e.Start.Format(timeLayout), e.Kind, e.Target, span, e.Detail)
+}
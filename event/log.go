@@ -0,0 +1,175 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yittg/ving/config"
+	eventconfig "github.com/yittg/ving/event/config"
+	"github.com/yittg/ving/statistic"
+	"github.com/yittg/ving/types"
+)
+
+// Log derives events of all targets, and keeps them in order of detection
+type Log struct {
+	cfg *eventconfig.Config
+
+	lock      sync.RWMutex
+	events    []*Event
+	markers   []*Event
+	detectors map[int]*detector
+	// overrides of config by target ID
+	overrides map[int]*eventconfig.Config
+}
+
+// NewLog new an event log with custom config
+func NewLog() *Log {
+	cfg := config.GetConfig().Event
+	return &Log{
+		cfg:       &cfg,
+		detectors: make(map[int]*detector),
+		overrides: make(map[int]*eventconfig.Config),
+	}
+}
+
+// Override thresholds of target `id`, zero values keep global ones
+func (l *Log) Override(id int, downAfter int, degradedLatency time.Duration) {
+	if downAfter == 0 && degradedLatency == 0 {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	cfg := *l.cfg
+	if downAfter > 0 {
+		cfg.DownAfter = downAfter
+	}
+	if degradedLatency > 0 {
+		cfg.DegradedLatency.Value = degradedLatency
+	}
+	l.overrides[id] = &cfg
+}
+
+// Observe the record of target dealt at `t` by `st`, returns events started or ended
+func (l *Log) Observe(t time.Time, record types.Record, st *statistic.Detail) []*Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	d, ok := l.detectors[record.ID]
+	if !ok {
+		cfg := l.cfg
+		if override, ok := l.overrides[record.ID]; ok {
+			cfg = override
+		}
+		d = newDetector(cfg, record.ID, st.Title)
+		l.detectors[record.ID] = d
+	}
+	changed := d.observe(t, record, st)
+	for _, e := range changed {
+		if e.Ongoing() {
+			l.events = append(l.events, e)
+		}
+	}
+	if over := len(l.events) - l.cfg.MaxEvents; over > 0 {
+		l.events = l.events[over:]
+	}
+	return changed
+}
+
+// Forget target `id` at `t`, its ongoing events are ended and returned
+func (l *Log) Forget(t time.Time, id int) []*Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.overrides, id)
+	d, ok := l.detectors[id]
+	if !ok {
+		return nil
+	}
+	delete(l.detectors, id)
+	return d.end(t)
+}
+
+// Mark drops a marker with note at `t`
+func (l *Log) Mark(t time.Time, note string) *Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	m := &Event{
+		ID:     -1,
+		Kind:   Marker,
+		Start:  t,
+		End:    t,
+		Detail: note,
+	}
+	l.markers = append(l.markers, m)
+	if over := len(l.markers) - l.cfg.MaxEvents; over > 0 {
+		l.markers = l.markers[over:]
+	}
+	l.events = append(l.events, m)
+	if over := len(l.events) - l.cfg.MaxEvents; over > 0 {
+		l.events = l.events[over:]
+	}
+	return m
+}
+
+// Note an instant event of target `id` at `t`, like address changed
+func (l *Log) Note(t time.Time, kind Kind, id int, target, detail string) *Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	e := &Event{
+		ID:     id,
+		Target: target,
+		Kind:   kind,
+		Start:  t,
+		End:    t,
+		Detail: detail,
+	}
+	l.events = append(l.events, e)
+	if over := len(l.events) - l.cfg.MaxEvents; over > 0 {
+		l.events = l.events[over:]
+	}
+	return e
+}
+
+// LatestMarker returns a copy of the latest marker, nil if none
+func (l *Log) LatestMarker() *Event {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if len(l.markers) == 0 {
+		return nil
+	}
+	m := *l.markers[len(l.markers)-1]
+	return &m
+}
+
+// Markers returns a snapshot of all markers kept, in order of time
+func (l *Log) Markers() []Event {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	markers := make([]Event, 0, len(l.markers))
+	for _, m := range l.markers {
+		markers = append(markers, *m)
+	}
+	return markers
+}
+
+// Events returns a snapshot of all events kept
+func (l *Log) Events() []Event {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	events := make([]Event, 0, len(l.events))
+	for _, e := range l.events {
+		events = append(events, *e)
+	}
+	return events
+}
+
+// Ongoing returns count of ongoing events
+func (l *Log) Ongoing() int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	n := 0
+	for _, e := range l.events {
+		if e.Ongoing() {
+			n++
+		}
+	}
+	return n
+}
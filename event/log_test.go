@@ -0,0 +1,25 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/event/config"
+)
+
+func TestLogMarkersCapped(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxEvents = 3
+	l := &Log{cfg: &cfg}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		l.Mark(start.Add(time.Duration(i)*time.Second), "note")
+	}
+	markers := l.Markers()
+	if len(markers) != 3 || !markers[0].Start.Equal(start.Add(2*time.Second)) {
+		t.Errorf("expect latest 3 markers kept, but got %v", markers)
+	}
+	if m := l.LatestMarker(); m == nil || !m.Start.Equal(start.Add(4*time.Second)) {
+		t.Errorf("unexpected latest marker %v", m)
+	}
+}
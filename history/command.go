@@ -0,0 +1,186 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"github.com/yittg/ving/config"
+)
+
+// CommandName of the history sub command
+const CommandName = "history"
+
+var levels = []rune("▁▂▃▄▅▆▇█")
+
+// parseSince parses duration like 36h, with `d` suffix supported, e.g. 7d
+func parseSince(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// period of aggregation, decided by `by` or the duration of history
+func periodOf(by string, since time.Duration) (time.Duration, error) {
+	switch by {
+	case "minute":
+		return time.Minute, nil
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	case "":
+		if since <= 2*24*time.Hour {
+			return time.Hour, nil
+		}
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown period %s, should be one of minute, hour, day", by)
+	}
+}
+
+// Command runs `ving history <target> [target...]`, returns the exit code
+func Command(args []string) int {
+	fs := flag.NewFlagSet(CommandName, flag.ContinueOnError)
+	historyConfig := config.GetConfig().History
+	path := fs.String("path", historyConfig.Path, "path of the history store")
+	sinceStr := fs.String("since", "7d", "how long ago to show, e.g. 12h, 7d")
+	by := fs.String("by", "", "aggregate by minute, hour or day, decided by --since by default")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [options] target [target...]\n", os.Args[0], CommandName)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() == 0 || *path == "" {
+		if *path == "" {
+			fmt.Fprintln(os.Stderr, "no history store, set path of [history] in configuration, or --path")
+		}
+		fs.Usage()
+		return 1
+	}
+	since, err := parseSince(*sinceStr)
+	if err != nil || since <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid --since %s\n", *sinceStr)
+		return 1
+	}
+	period, err := periodOf(*by, since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	from := time.Now().Add(-since)
+	markers, err := LoadMarkers(*path, from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load history error, %v\n", err)
+		return 1
+	}
+	for _, target := range fs.Args() {
+		aggregates, err := Load(*path, target, from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "load history error, %v\n", err)
+			return 1
+		}
+		Print(os.Stdout, target, from, period, aggregates, markers)
+	}
+	return 0
+}
+
+// rollup aggregates by period
+func rollup(aggregates []Aggregate, period time.Duration) []Aggregate {
+	var periods []Aggregate
+	for i := range aggregates {
+		a := aggregates[i]
+		start := time.Unix(a.Minute, 0).Truncate(period)
+		if period >= 24*time.Hour {
+			// align with the local day
+			t := time.Unix(a.Minute, 0)
+			start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		}
+		if n := len(periods); n > 0 && periods[n-1].Minute == start.Unix() {
+			periods[n-1].merge(&a)
+			continue
+		}
+		a.Minute = start.Unix()
+		periods = append(periods, a)
+	}
+	return periods
+}
+
+func level(v, max float64) string {
+	if max <= 0 || v <= 0 {
+		return " "
+	}
+	idx := int(v / max * float64(len(levels)-1))
+	return string(levels[idx])
+}
+
+func formatCost(cost int64) string {
+	if cost == math.MaxInt64 {
+		return "-"
+	}
+	return time.Duration(cost).Truncate(10 * time.Microsecond).String()
+}
+
+// Print history of target aggregated by period, with charts of loss and latency,
+// markers are printed after the period they were dropped in
+func Print(w io.Writer, target string, from time.Time, period time.Duration, aggregates []Aggregate, markers []Marker) {
+	layout := "2006-01-02 15:04"
+	if period >= 24*time.Hour {
+		layout = "2006-01-02"
+	}
+	by := map[time.Duration]string{time.Minute: "minute", time.Hour: "hour", 24 * time.Hour: "day"}[period]
+	header := target
+	if n := len(aggregates); n > 0 && aggregates[n-1].Title != "" {
+		header = fmt.Sprintf("%s (%s)", target, aggregates[n-1].Title)
+	}
+	fmt.Fprintf(w, "%s since %s, by %s\n", header, from.Format("2006-01-02 15:04"), by)
+	periods := rollup(aggregates, period)
+	if len(periods) == 0 {
+		fmt.Fprintln(w, "    no history")
+		return
+	}
+	maxLoss, maxCost := 0.0, 0.0
+	for i := range periods {
+		maxLoss = math.Max(maxLoss, periods[i].LossRate())
+		if cost := periods[i].AverageCost(); cost != math.MaxInt64 {
+			maxCost = math.Max(maxCost, float64(cost))
+		}
+	}
+	fmt.Fprintf(w, "    %-16s %10s %8s %10s %10s %10s  %s %s\n",
+		"period", "rounds", "loss", "avg", "min", "max", "L", "A")
+	nextMarker := 0
+	for i := range periods {
+		p := &periods[i]
+		avg, min, max := p.AverageCost(), int64(math.MaxInt64), int64(math.MaxInt64)
+		if avg != math.MaxInt64 {
+			min, max = p.MinCost, p.MaxCost
+		}
+		avgLevel := " "
+		if avg != math.MaxInt64 {
+			avgLevel = level(float64(avg), maxCost)
+		}
+		fmt.Fprintf(w, "    %-16s %10d %7.2f%% %10s %10s %10s  %s %s\n",
+			time.Unix(p.Minute, 0).Format(layout), p.Count, p.LossRate()*100,
+			formatCost(avg), formatCost(min), formatCost(max), level(p.LossRate(), maxLoss), avgLevel)
+		var end int64 = math.MaxInt64
+		if i+1 < len(periods) {
+			end = periods[i+1].Minute
+		}
+		for ; nextMarker < len(markers) && markers[nextMarker].At.Unix() < end; nextMarker++ {
+			m := markers[nextMarker]
+			fmt.Fprintf(w, "    ┊ %s %s\n", m.At.Format("2006-01-02 15:04:05"), m.Note)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	c "github.com/yittg/ving/config/encoding"
+	"github.com/yittg/ving/errors"
+)
+
+// Config of the persistent history store
+type Config struct {
+	// Path of the store file, empty means disabled
+	Path string `toml:"path"`
+
+	// Retention of aggregates, older ones are dropped when opened, 0 means forever
+	Retention c.Duration `toml:"retention"`
+}
+
+// Validate the history config
+func (c *Config) Validate() error {
+	if c.Retention.Value < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("retention of history should not be negative, (retention=%v)", c.Retention.Value),
+		}
+	}
+	return nil
+}
+
+// Default config of history, disabled
+func Default() Config {
+	return Config{}
+}
@@ -0,0 +1,269 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+// Aggregate of records of a target in a minute, or a marker if Note is not empty
+type Aggregate struct {
+	// Target keyed by its address, and Title to display if different
+	Target string `json:"target"`
+	Title  string `json:"title,omitempty"`
+	// Minute in unix seconds, truncated to minute, except that of markers
+	Minute int64 `json:"minute"`
+	// Note of the marker, markers have no target
+	Note   string `json:"note,omitempty"`
+	Count  int    `json:"count"`
+	Errors int    `json:"errors"`
+	// SumCost, MinCost and MaxCost of successful records in nanoseconds
+	SumCost int64 `json:"sum_ns"`
+	MinCost int64 `json:"min_ns"`
+	MaxCost int64 `json:"max_ns"`
+}
+
+func (a *Aggregate) add(record types.Record) {
+	a.Count++
+	if !record.Successful {
+		a.Errors++
+		return
+	}
+	cost := int64(record.Cost)
+	if a.Count-a.Errors == 1 || cost < a.MinCost {
+		a.MinCost = cost
+	}
+	if cost > a.MaxCost {
+		a.MaxCost = cost
+	}
+	a.SumCost += cost
+}
+
+func (a *Aggregate) merge(other *Aggregate) {
+	if other.Count-other.Errors > 0 {
+		if a.Count-a.Errors == 0 || other.MinCost < a.MinCost {
+			a.MinCost = other.MinCost
+		}
+		if other.MaxCost > a.MaxCost {
+			a.MaxCost = other.MaxCost
+		}
+	}
+	a.Count += other.Count
+	a.Errors += other.Errors
+	a.SumCost += other.SumCost
+}
+
+// LossRate of records
+func (a *Aggregate) LossRate() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return float64(a.Errors) / float64(a.Count)
+}
+
+// AverageCost of successful records, math.MaxInt64 if none
+func (a *Aggregate) AverageCost() int64 {
+	successful := a.Count - a.Errors
+	if successful <= 0 {
+		return math.MaxInt64
+	}
+	return a.SumCost / int64(successful)
+}
+
+// Store persists per-target per-minute aggregates into a file as json lines
+type Store struct {
+	f       *os.File
+	w       *bufio.Writer
+	pending map[string]*Aggregate
+}
+
+// expandPath expands leading ~ as home directory
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+	return path
+}
+
+// Open the store at path, aggregates older than `retention` are dropped if it's positive
+func Open(path string, retention time.Duration) (*Store, error) {
+	path = expandPath(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if retention > 0 {
+		if err := compact(path, time.Now().Add(-retention)); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		f:       f,
+		w:       bufio.NewWriter(f),
+		pending: make(map[string]*Aggregate),
+	}, nil
+}
+
+// Add the record of target titled `title` dealt at `t`, aggregates of past minutes are written then
+func (s *Store) Add(target, title string, t time.Time, record types.Record) error {
+	minute := t.Truncate(time.Minute).Unix()
+	a, ok := s.pending[target]
+	if ok && a.Minute != minute {
+		if err := s.write(a); err != nil {
+			return err
+		}
+		if err := s.w.Flush(); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if !ok {
+		a = &Aggregate{Target: target, Minute: minute}
+		if title != target {
+			a.Title = title
+		}
+		s.pending[target] = a
+	}
+	a.add(record)
+	return nil
+}
+
+// Mark writes a marker with note dropped at `t` immediately
+func (s *Store) Mark(t time.Time, note string) error {
+	if err := s.write(&Aggregate{Minute: t.Unix(), Note: note}); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func (s *Store) write(a *Aggregate) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// Close the store, pending aggregates are written
+func (s *Store) Close() error {
+	targets := make([]string, 0, len(s.pending))
+	for target := range s.pending {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if err := s.write(s.pending[target]); err != nil {
+			return err
+		}
+	}
+	s.pending = nil
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.f.Close()
+}
+
+// scan aggregates from reader, broken lines are skipped
+func scan(r io.Reader, f func(*Aggregate)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var a Aggregate
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue
+		}
+		f(&a)
+	}
+	return scanner.Err()
+}
+
+// Load aggregates of target since `since` from the store at path, in order of time
+func Load(path, target string, since time.Time) ([]Aggregate, error) {
+	f, err := os.Open(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var aggregates []Aggregate
+	err = scan(f, func(a *Aggregate) {
+		if a.Note == "" && a.Target == target && a.Minute >= since.Unix() {
+			aggregates = append(aggregates, *a)
+		}
+	})
+	sort.SliceStable(aggregates, func(i, j int) bool { return aggregates[i].Minute < aggregates[j].Minute })
+	return aggregates, err
+}
+
+// Marker dropped at a time with note
+type Marker struct {
+	At   time.Time
+	Note string
+}
+
+// LoadMarkers since `since` from the store at path, in order of time
+func LoadMarkers(path string, since time.Time) ([]Marker, error) {
+	f, err := os.Open(expandPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var markers []Marker
+	err = scan(f, func(a *Aggregate) {
+		if a.Note != "" && a.Minute >= since.Unix() {
+			markers = append(markers, Marker{At: time.Unix(a.Minute, 0), Note: a.Note})
+		}
+	})
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].At.Before(markers[j].At) })
+	return markers, err
+}
+
+// compact drops aggregates before `before` by rewriting the store
+func compact(path string, before time.Time) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var kept []*Aggregate
+	dropped := false
+	err = scan(f, func(a *Aggregate) {
+		if a.Minute < before.Unix() {
+			dropped = true
+			return
+		}
+		kept = append(kept, a)
+	})
+	f.Close()
+	if err != nil || !dropped {
+		return err
+	}
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	s := &Store{f: out, w: bufio.NewWriter(out)}
+	for _, a := range kept {
+		if err := s.write(a); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := s.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
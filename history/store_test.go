@@ -0,0 +1,94 @@
+package history
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestStoreAddAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Truncate(time.Minute).Add(-time.Hour)
+	for i := 0; i < 180; i++ {
+		record := types.Record{Successful: i%10 != 0, Cost: time.Duration(i%60+1) * time.Millisecond}
+		at := start.Add(time.Duration(i) * time.Second)
+		if err := s.Add("a", "a", at, record); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Add("b", "renamed", at, record); err != nil {
+			t.Fatal(err)
+		}
+		if i == 90 {
+			if err := s.Mark(at, "switched to backup link"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if markers, err := LoadMarkers(path, start); err != nil || len(markers) != 1 ||
+		markers[0].Note != "switched to backup link" || !markers[0].At.Equal(start.Add(90*time.Second)) {
+		t.Errorf("unexpected markers %v, %v", markers, err)
+	}
+
+	aggregates, err := Load(path, "a", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregates) != 3 {
+		t.Fatalf("expect 3 minutes, but got %d", len(aggregates))
+	}
+	for _, a := range aggregates {
+		if a.Count != 60 || a.Errors != 6 || a.MinCost != int64(2*time.Millisecond) || a.MaxCost != int64(60*time.Millisecond) {
+			t.Errorf("unexpected aggregate %+v", a)
+		}
+	}
+
+	// reopen with retention drops those before
+	s, err = Open(path, time.Since(start.Add(2*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+	if aggregates, _ = Load(path, "b", start); len(aggregates) != 1 || aggregates[0].Title != "renamed" {
+		t.Errorf("expect 1 minute of b titled renamed kept, but got %+v", aggregates)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	var aggregates []Aggregate
+	for i := 0; i < 48*60; i += 30 {
+		aggregates = append(aggregates, Aggregate{
+			Target: "a", Minute: start.Add(time.Duration(i) * time.Minute).Unix(),
+			Count: 60, Errors: i / (24 * 60), SumCost: 60 * int64(time.Millisecond),
+			MinCost: int64(time.Millisecond), MaxCost: int64(time.Millisecond),
+		})
+	}
+	markers := []Marker{{At: start.Add(time.Hour), Note: "switched to backup link"}}
+	var buf bytes.Buffer
+	Print(&buf, "a", start, 24*time.Hour, aggregates, markers)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.Contains(lines[2], "2026-10-01") ||
+		!strings.Contains(lines[3], "switched to backup link") || !strings.Contains(lines[4], "1.67%") {
+		t.Errorf("unexpected history output:\n%s", buf.String())
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	if d, err := parseSince("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("expect 7 days, but got %v, %v", d, err)
+	}
+	if d, err := parseSince("36h"); err != nil || d != 36*time.Hour {
+		t.Errorf("expect 36 hours, but got %v, %v", d, err)
+	}
+}
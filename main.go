@@ -8,11 +8,16 @@ import (
 	"github.com/yittg/ving/common"
 	_ "github.com/yittg/ving/config"
 	"github.com/yittg/ving/core"
+	"github.com/yittg/ving/history"
 	"github.com/yittg/ving/options"
 	"github.com/yittg/ving/version"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == history.CommandName {
+		os.Exit(history.Command(os.Args[2:]))
+	}
+
 	opt := options.Option{}
 	targets := options.ParseCommandLine(&opt)
 	if opt.ShowVersion {
@@ -26,5 +31,5 @@ func main() {
 		common.ErrExit("", err, 1)
 	}
 	ctx := context.Background()
-	engine.Run(ctx)
+	os.Exit(engine.Run(ctx))
 }
@@ -0,0 +1,23 @@
+// Package netns runs functions in network namespaces, e.g. to open sockets in the namespace of a pod
+package netns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namedDir where named network namespaces are mounted by `ip netns`
+const namedDir = "/var/run/netns/"
+
+// Path of the network namespace, a name like foo is of `ip netns`, a path is kept as it is
+func Path(nameOrPath string) string {
+	if nameOrPath == "" || strings.ContainsRune(nameOrPath, '/') {
+		return nameOrPath
+	}
+	return namedDir + nameOrPath
+}
+
+// PathOfPid returns the path of the network namespace of process `pid`
+func PathOfPid(pid int) string {
+	return fmt.Sprintf("/proc/%d/ns/net", pid)
+}
@@ -0,0 +1,11 @@
+package netns
+
+import "fmt"
+
+// Do runs `f` in the current namespace, network namespaces are not supported but on linux
+func Do(path string, f func() error) error {
+	if path == "" {
+		return f()
+	}
+	return fmt.Errorf("network namespace %s is not supported but on linux", path)
+}
@@ -0,0 +1,73 @@
+package netns
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// Do runs `f` in the network namespace at path on a dedicated locked thread, in the current namespace if path is empty.
+// Sockets opened by `f` stay in the namespace after returned.
+func Do(path string, f func() error) error {
+	if path == "" {
+		return f()
+	}
+	target, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- doLocked(path, target, f)
+	}()
+	return <-done
+}
+
+// doLocked runs `f` in the namespace on the locked thread of a dedicated goroutine.
+// The thread is unlocked only if it's back in the original namespace,
+// otherwise it's discarded as the goroutine exits locked
+func doLocked(path string, target *os.File, f func() error) error {
+	runtime.LockOSThread()
+	origin, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer origin.Close()
+	if err := setns(target.Fd()); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("enter network namespace %s, %v", path, err)
+	}
+	fErr := f()
+	if err := setns(origin.Fd()); err != nil {
+		return fmt.Errorf("restore network namespace, %v", err)
+	}
+	runtime.UnlockOSThread()
+	return fErr
+}
+
+// setnsTraps of setns by architecture, which are missing in package syscall of some
+var setnsTraps = map[string]uintptr{
+	"386":      346,
+	"amd64":    308,
+	"arm":      375,
+	"arm64":    268,
+	"mips64le": 5303,
+	"ppc64le":  350,
+	"riscv64":  268,
+	"s390x":    339,
+}
+
+func setns(fd uintptr) error {
+	trap, ok := setnsTraps[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("setns is not supported on %s", runtime.GOARCH)
+	}
+	if _, _, errno := syscall.RawSyscall(trap, fd, syscall.CLONE_NEWNET, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package netns
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	called := false
+	if err := Do("", func() error { called = true; return nil }); err != nil || !called {
+		t.Errorf("expect called in the current namespace, but got %v", err)
+	}
+	if err := Do("/nonexistent/ns/net", func() error { return nil }); err == nil {
+		t.Error("expect error of nonexistent namespace")
+	}
+	// entering the current namespace needs privileges, f is called with the error returned
+	expected := fmt.Errorf("failed in namespace")
+	err := Do("/proc/self/ns/net", func() error { return expected })
+	if err != expected {
+		t.Skipf("unable to enter network namespace, %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package netns
+
+import "testing"
+
+func TestPath(t *testing.T) {
+	for nameOrPath, expected := range map[string]string{
+		"":                   "",
+		"foo":                "/var/run/netns/foo",
+		"/var/run/netns/bar": "/var/run/netns/bar",
+		"/proc/1/ns/net":     "/proc/1/ns/net",
+	} {
+		if path := Path(nameOrPath); path != expected {
+			t.Errorf("expect path %s of %s, but got %s", expected, nameOrPath, path)
+		}
+	}
+	if path := PathOfPid(1234); path != "/proc/1234/ns/net" {
+		t.Errorf("unexpected path of pid, %s", path)
+	}
+}
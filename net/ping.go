@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/yittg/ving/net/protocol"
@@ -15,6 +16,9 @@ import (
 type NPing struct {
 	icmpPing *icmp.IPing
 	tcpPing  *tcp.TPing
+
+	// flows of echo requests to targets
+	flows sync.Map
 }
 
 // NewPing new a ping
@@ -34,18 +38,68 @@ func (p *NPing) Start(ctx context.Context) (err error) {
 func (p *NPing) PingOnce(target *protocol.NetworkTarget, timeout time.Duration) (time.Duration, error) {
 	switch target.Typ {
 	case protocol.IP:
-		return p.icmpPing.Ping(target.Target.(*net.IPAddr), timeout)
+		return p.icmpPing.PingFlow(p.flowOf(target), target.Target.(*net.IPAddr), target.Origin, timeout)
 	case protocol.TCP:
-		return p.tcpPing.Touch(target.Target.(*net.TCPAddr), timeout)
+		return p.tcpPing.Touch(target.Target.(*net.TCPAddr), target.Origin, timeout)
 	default:
 		return 0, fmt.Errorf("unsupported network type, %v", target.Typ)
 	}
 }
 
+// flowOf the target, echo requests to a target share an ID with increasing sequences
+func (p *NPing) flowOf(target *protocol.NetworkTarget) *icmp.Flow {
+	if f, ok := p.flows.Load(target); ok {
+		return f.(*icmp.Flow)
+	}
+	f := p.icmpPing.NewFlow()
+	actual, loaded := p.flows.LoadOrStore(target, f)
+	if loaded {
+		p.icmpPing.Close(f)
+	}
+	return actual.(*icmp.Flow)
+}
+
+// Forget the target not to be pinged any more, replies to it are ignored then
+func (p *NPing) Forget(target *protocol.NetworkTarget) {
+	if f, ok := p.flows.LoadAndDelete(target); ok {
+		p.icmpPing.Close(f.(*icmp.Flow))
+	}
+}
+
+// Succeed the target by `successor` of another address, replies to the target are ignored then,
+// and abnormal replies accumulated are carried over to the successor
+func (p *NPing) Succeed(target, successor *protocol.NetworkTarget) {
+	f, ok := p.flows.LoadAndDelete(target)
+	if !ok {
+		return
+	}
+	p.icmpPing.Close(f.(*icmp.Flow))
+	if successor.Typ == protocol.IP {
+		p.flowOf(successor).Carry(f.(*icmp.Flow).Stats())
+	}
+}
+
+// EchoStats of replies of the target, false if not an IP target pinged yet
+func (p *NPing) EchoStats(target *protocol.NetworkTarget) (icmp.FlowStats, bool) {
+	f, ok := p.flows.Load(target)
+	if !ok {
+		return icmp.FlowStats{}, false
+	}
+	return f.(*icmp.Flow).Stats(), true
+}
+
+// Grab connects to tcp target, and reads banner in `wait`
+func (p *NPing) Grab(target *protocol.NetworkTarget, timeout, wait time.Duration) (time.Duration, string, error) {
+	if target.Typ != protocol.TCP {
+		return 0, "", fmt.Errorf("unsupported network type, %v", target.Typ)
+	}
+	return p.tcpPing.Grab(target.Target.(*net.TCPAddr), target.Origin, timeout, wait)
+}
+
 // Trace to target with address as `addr`
 func (p *NPing) Trace(target *protocol.NetworkTarget, ttl int, timeout time.Duration) (time.Duration, net.Addr, error) {
 	if target.Typ != protocol.IP {
 		return 0, nil, fmt.Errorf("unsupported network type, %v", target.Typ)
 	}
-	return p.icmpPing.Trace(target.Target.(*net.IPAddr), ttl, timeout)
+	return p.icmpPing.Trace(target.Target.(*net.IPAddr), target.Origin, ttl, timeout)
 }
@@ -1,6 +1,7 @@
 package net
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -9,9 +10,8 @@ import (
 )
 
 func Example() {
-	stopChan := make(chan bool, 2)
-	p := NewPing(stopChan)
-	if err := p.Start(); err != nil {
+	p := NewPing()
+	if err := p.Start(context.Background()); err != nil {
 		log.Fatalf("start nping failed, %v", err)
 	}
 
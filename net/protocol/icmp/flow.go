@@ -0,0 +1,193 @@
+package icmp
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/net/protocol"
+)
+
+// seqHistory is how many recent sequences are remembered to detect duplicate and late replies
+const seqHistory = 1024
+
+// FlowStats of echo replies of a flow
+type FlowStats struct {
+	// Seq of the latest echo request sent
+	Seq int
+	// Duplicates of replies to a sequence already replied
+	Duplicates int
+	// Reordered replies arrived after a reply to a later sequence
+	Reordered int
+	// Late replies arrived after timeout, which are counted as loss already
+	Late int
+}
+
+type seqState struct {
+	replied bool
+	// waiting for the reply, nil if delivered or expired
+	waiting chan *packet
+	// via the conn sent, replies received by other conns are ignored
+	via *connSource
+}
+
+// Flow of echo requests to a target with the same ID and increasing sequences
+type Flow struct {
+	id int
+
+	// sendLock serializes requests, so that they are sent in order of sequences even if concurrent
+	sendLock    sync.Mutex
+	lock        sync.Mutex
+	nextSeq     uint16
+	sent        map[uint16]*seqState
+	lastReplied uint16
+	anyReplied  bool
+	stats       FlowStats
+}
+
+// seqAfter represents whether sequence `a` is after `b`, with wrap around considered
+func seqAfter(a, b uint16) bool {
+	return int16(a-b) > 0
+}
+
+// NewFlow allocates an echo ID for a new flow
+func (p *IPing) NewFlow() *Flow {
+	f := &Flow{
+		sent: make(map[uint16]*seqState),
+	}
+	for {
+		id := rand.Intn(1 << 16)
+		if _, loaded := p.sessions.LoadOrStore(id, f); !loaded {
+			f.id = id
+			return f
+		}
+	}
+}
+
+// Close the flow, replies to it are ignored then
+func (p *IPing) Close(f *Flow) {
+	p.sessions.Delete(f.id)
+}
+
+// Stats of replies of the flow
+func (f *Flow) Stats() FlowStats {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.stats
+}
+
+// Carry abnormal replies accumulated by a former flow, e.g. to a target of which address changed,
+// the sequence is not carried
+func (f *Flow) Carry(former FlowStats) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stats.Duplicates += former.Duplicates
+	f.stats.Reordered += former.Reordered
+	f.stats.Late += former.Late
+}
+
+func (f *Flow) nextRequest(via *connSource) (uint16, *seqState, <-chan *packet) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	seq := f.nextSeq
+	f.nextSeq++
+	delete(f.sent, seq-seqHistory)
+	ch := make(chan *packet, 1)
+	state := &seqState{waiting: ch, via: via}
+	f.sent[seq] = state
+	f.stats.Seq = int(seq)
+	return seq, state, ch
+}
+
+// request of the next sequence sent by `send`, the sequence is expired if failed to send
+func (f *Flow) request(via *connSource, send func(seq uint16) error) (*seqState, <-chan *packet, error) {
+	f.sendLock.Lock()
+	defer f.sendLock.Unlock()
+	seq, state, reply := f.nextRequest(via)
+	if err := send(seq); err != nil {
+		f.expire(state)
+		return nil, nil, err
+	}
+	return state, reply, nil
+}
+
+// expire the sequence after timeout, a reply arrives later is late
+func (f *Flow) expire(state *seqState) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if state.replied && state.waiting == nil {
+		// replied just after timeout
+		f.stats.Late++
+	}
+	state.waiting = nil
+}
+
+// receive see `receiver`
+func (f *Flow) receive(pkt *packet, seq int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	s := uint16(seq)
+	state, ok := f.sent[s]
+	if !ok || pkt.source != state.via {
+		return
+	}
+	if pkt.typ != pkt.source.pd.relTyp {
+		if state.waiting != nil && !state.replied {
+			state.waiting <- pkt
+			state.waiting = nil
+		}
+		return
+	}
+	if state.replied {
+		f.stats.Duplicates++
+		return
+	}
+	state.replied = true
+	if state.waiting == nil {
+		// counted as loss already, so not reordered
+		f.stats.Late++
+		return
+	}
+	state.waiting <- pkt
+	state.waiting = nil
+	if f.anyReplied && seqAfter(f.lastReplied, s) {
+		f.stats.Reordered++
+		return
+	}
+	f.anyReplied = true
+	f.lastReplied = s
+}
+
+func (p *IPing) pingFlow(f *Flow, ipAddr *net.IPAddr, c *connSource, timeout time.Duration) (time.Duration, error) {
+	var since time.Time
+	state, reply, err := f.request(c, func(seq uint16) (err error) {
+		since, err = p.sendEcho(ipAddr, c, f.id, int(seq))
+		return
+	})
+	if err != nil {
+		return 0, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		f.expire(state)
+		return 0, &errors.ErrTimeout{}
+	case pkt := <-reply:
+		if pkt.typ != c.pd.relTyp {
+			return 0, &errors.ErrTimeout{}
+		}
+		return pkt.echoAt.Sub(since), nil
+	}
+}
+
+// PingFlow pings ipAddr from the origin with timeout as a request of the flow
+func (p *IPing) PingFlow(f *Flow, ipAddr *net.IPAddr, origin protocol.Origin, timeout time.Duration) (time.Duration, error) {
+	c, err := p.connOf(ipAddr, origin)
+	if err != nil {
+		return 0, err
+	}
+	return p.pingFlow(f, ipAddr, c, timeout)
+}
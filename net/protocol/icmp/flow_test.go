@@ -0,0 +1,99 @@
+package icmp
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFlowReceive(t *testing.T) {
+	source := &connSource{pd: protoMap[4]}
+	reply := &packet{source: source, typ: source.pd.relTyp}
+	f := &Flow{sent: make(map[uint16]*seqState)}
+
+	seq0, _, ch0 := f.nextRequest(source)
+	seq1, _, ch1 := f.nextRequest(source)
+	seq2, state2, ch2 := f.nextRequest(source)
+
+	// reply received by another conn is ignored
+	f.receive(&packet{source: &connSource{pd: protoMap[4]}, typ: source.pd.relTyp}, int(seq0))
+
+	// reply of seq1 arrives before seq0, then duplicated
+	f.receive(reply, int(seq1))
+	f.receive(reply, int(seq0))
+	f.receive(reply, int(seq1))
+	if len(ch0) != 1 || len(ch1) != 1 {
+		t.Fatal("expect replies delivered")
+	}
+
+	// seq2 timeout, then its reply arrives late
+	f.expire(state2)
+	f.receive(reply, int(seq2))
+	if len(ch2) != 0 {
+		t.Fatal("expect late reply not delivered")
+	}
+
+	stats := f.Stats()
+	expected := FlowStats{Seq: int(seq2), Duplicates: 1, Reordered: 1, Late: 1}
+	if stats != expected {
+		t.Errorf("expect stats %+v, but got %+v", expected, stats)
+	}
+}
+
+func TestFlowLateNotReordered(t *testing.T) {
+	source := &connSource{pd: protoMap[4]}
+	reply := &packet{source: source, typ: source.pd.relTyp}
+	f := &Flow{sent: make(map[uint16]*seqState)}
+
+	seq0, state0, _ := f.nextRequest(source)
+	seq1, _, _ := f.nextRequest(source)
+
+	// seq0 timeout, seq1 replied, then the reply of seq0 arrives late
+	f.expire(state0)
+	f.receive(reply, int(seq1))
+	f.receive(reply, int(seq0))
+
+	stats := f.Stats()
+	if stats.Late != 1 || stats.Reordered != 0 {
+		t.Errorf("expect late reply not reordered, but got %+v", stats)
+	}
+}
+
+func TestFlowRequestInOrder(t *testing.T) {
+	source := &connSource{pd: protoMap[4]}
+	f := &Flow{sent: make(map[uint16]*seqState)}
+	var sent []uint16
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = f.request(source, func(seq uint16) error {
+				sent = append(sent, seq)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	for i, seq := range sent {
+		if int(seq) != i {
+			t.Fatalf("expect requests sent in order of sequences, but got %v", sent)
+		}
+	}
+}
+
+func TestFlowCarry(t *testing.T) {
+	source := &connSource{pd: protoMap[4]}
+	f := &Flow{sent: make(map[uint16]*seqState)}
+	f.nextRequest(source)
+	f.Carry(FlowStats{Seq: 100, Duplicates: 1, Reordered: 2, Late: 3})
+	expected := FlowStats{Seq: 0, Duplicates: 1, Reordered: 2, Late: 3}
+	if stats := f.Stats(); stats != expected {
+		t.Errorf("expect stats %+v carried without sequence, but got %+v", expected, stats)
+	}
+}
+
+func TestSeqAfter(t *testing.T) {
+	if !seqAfter(1, 0) || seqAfter(0, 1) || !seqAfter(0, 65535) {
+		t.Error("unexpected order of sequences")
+	}
+}
@@ -2,12 +2,15 @@ package icmp
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"net"
 	"sync"
 	"time"
 
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/net/netns"
+	"github.com/yittg/ving/net/protocol"
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
@@ -17,6 +20,11 @@ import (
 type IPing struct {
 	conn   *connSource
 	connV6 *connSource
+	// bound conns by origin, opened on demand
+	bound     sync.Map
+	boundLock sync.Mutex
+	// ctx of conns, set when started
+	ctx context.Context
 
 	sessions sync.Map
 }
@@ -50,11 +58,25 @@ type packet struct {
 	n     int
 }
 
+// receiver of packets replied to an echo ID
+type receiver interface {
+	receive(pkt *packet, seq int)
+}
+
+// session of a single echo request, e.g. for trace
 type session struct {
 	id int
 	ch chan *packet
 }
 
+// receive see `receiver`, drops the packet if one received already
+func (s *session) receive(pkt *packet, _ int) {
+	select {
+	case s.ch <- pkt:
+	default:
+	}
+}
+
 // NewPing new a ping
 func NewPing() *IPing {
 	return &IPing{
@@ -68,8 +90,12 @@ func newSession() *session {
 	}
 }
 
-func (p *IPing) newConn(network string, version int) (*connSource, error) {
-	c, err := icmp.ListenPacket(network, "")
+func (p *IPing) newConn(network string, version int, source net.IP) (*connSource, error) {
+	address := ""
+	if source != nil {
+		address = source.String()
+	}
+	c, err := icmp.ListenPacket(network, address)
 	if err != nil {
 		return nil, err
 	}
@@ -80,21 +106,62 @@ func (p *IPing) newConn(network string, version int) (*connSource, error) {
 	}, nil
 }
 
-func (p *IPing) newIPv4Conn() (*connSource, error) {
-	return p.newConn(networkType["ipv4"], 4)
+func (p *IPing) newIPv4Conn(source net.IP) (*connSource, error) {
+	return p.newConn(networkType["ipv4"], 4, source)
 }
 
-func (p *IPing) newIPv6Conn() (*connSource, error) {
-	return p.newConn(networkType["ipv6"], 6)
+func (p *IPing) newIPv6Conn(source net.IP) (*connSource, error) {
+	return p.newConn(networkType["ipv6"], 6, source)
+}
+
+// connOf ipAddr to send from the origin, bound conns are opened on demand, default ones for default origin
+func (p *IPing) connOf(ipAddr *net.IPAddr, origin protocol.Origin) (*connSource, error) {
+	v4 := ipAddr.IP.To4() != nil
+	if origin.IsDefault() {
+		if v4 {
+			return p.conn, nil
+		}
+		return p.connV6, nil
+	}
+	key := fmt.Sprintf("%s|%s|%v", origin.Netns, origin.Source, v4)
+	if c, ok := p.bound.Load(key); ok {
+		return c.(*connSource), nil
+	}
+	p.boundLock.Lock()
+	defer p.boundLock.Unlock()
+	if c, ok := p.bound.Load(key); ok {
+		return c.(*connSource), nil
+	}
+	c, err := p.newOriginConn(v4, origin)
+	if err != nil {
+		return nil, err
+	}
+	p.startConn(p.ctx, c)
+	p.bound.Store(key, c)
+	return c, nil
+}
+
+// newOriginConn of IPv4 or IPv6 bound to the source, opened in the network namespace of the origin
+func (p *IPing) newOriginConn(v4 bool, origin protocol.Origin) (c *connSource, err error) {
+	err = netns.Do(origin.Netns, func() (err error) {
+		if v4 {
+			c, err = p.newIPv4Conn(origin.Source)
+		} else {
+			c, err = p.newIPv6Conn(origin.Source)
+		}
+		return
+	})
+	return
 }
 
 // Start listen
 func (p *IPing) Start(ctx context.Context) (err error) {
-	p.conn, err = p.newIPv4Conn()
+	p.ctx = ctx
+	p.conn, err = p.newIPv4Conn(nil)
 	if err != nil {
 		return
 	}
-	p.connV6, err = p.newIPv6Conn()
+	p.connV6, err = p.newIPv6Conn(nil)
 	if err != nil {
 		return
 	}
@@ -166,19 +233,19 @@ func (p *IPing) parseMsg(pkt *packet) {
 	}
 	pkt.typ = m.Type
 
-	enSessionCh := func(sid int) {
-		if s, ok := p.sessions.Load(sid); ok {
-			s.(*session).ch <- pkt
+	enSessionCh := func(sid, seq int) {
+		if r, ok := p.sessions.Load(sid); ok {
+			r.(receiver).receive(pkt, seq)
 		}
 	}
 
 	if echo, ok := m.Body.(*icmp.Echo); ok {
-		enSessionCh(echo.ID)
+		enSessionCh(echo.ID, echo.Seq)
 	} else if tex, ok := m.Body.(*icmp.TimeExceeded); ok {
 		if dat, err := ipv4.ParseHeader(tex.Data); err == nil {
 			originPkt, _ := icmp.ParseMessage(pkt.source.pd.proto, tex.Data[dat.Len:])
 			if echo, ok := originPkt.Body.(*icmp.Echo); ok {
-				enSessionCh(echo.ID)
+				enSessionCh(echo.ID, echo.Seq)
 			}
 		}
 	}
@@ -195,23 +262,32 @@ func (p *IPing) send(ipAddr *net.IPAddr, c *connSource) (*time.Time, *session, e
 			break
 		}
 	}
+	t, err := p.sendEcho(ipAddr, c, sid, 0)
+	if err != nil {
+		p.finishSession(s)
+		return nil, nil, err
+	}
+	return &t, s, nil
+}
+
+func (p *IPing) sendEcho(ipAddr *net.IPAddr, c *connSource, id, seq int) (time.Time, error) {
 	bytes, err := (&icmp.Message{
 		Type: c.pd.reqTyp,
 		Code: 0,
 		Body: &icmp.Echo{
-			ID:   sid,
-			Seq:  0,
+			ID:   id,
+			Seq:  seq,
 			Data: []byte{0, 1, 2},
 		},
 	}).Marshal(nil)
 	if err != nil {
-		return nil, nil, err
+		return time.Time{}, err
 	}
 	t := time.Now()
 	if _, err := c.c.WriteTo(bytes, p.buildDst(ipAddr)); err != nil {
-		return nil, nil, err
+		return time.Time{}, err
 	}
-	return &t, s, nil
+	return t, nil
 }
 
 func (p *IPing) finishSession(s *session) {
@@ -249,21 +325,16 @@ func (p *IPing) Ping(ipAddr *net.IPAddr, timeout time.Duration) (latency time.Du
 	return
 }
 
-// Trace ipAddr with timeout
-func (p *IPing) Trace(ipAddr *net.IPAddr, ttl int, timeout time.Duration) (time.Duration, net.Addr, error) {
-	var c *connSource
-	var err error
-	if ipAddr.IP.To4() != nil {
-		c, err = p.newIPv4Conn()
-		if err != nil {
-			return 0, nil, err
-		}
+// Trace ipAddr from the origin with timeout
+func (p *IPing) Trace(ipAddr *net.IPAddr, origin protocol.Origin, ttl int, timeout time.Duration) (time.Duration, net.Addr, error) {
+	v4 := ipAddr.IP.To4() != nil
+	c, err := p.newOriginConn(v4, origin)
+	if err != nil {
+		return 0, nil, err
+	}
+	if v4 {
 		err = c.c.IPv4PacketConn().SetTTL(ttl)
 	} else {
-		c, err = p.newIPv6Conn()
-		if err != nil {
-			return 0, nil, err
-		}
 		err = c.c.IPv6PacketConn().SetHopLimit(ttl)
 	}
 	if err != nil {
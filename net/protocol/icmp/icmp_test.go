@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/net/protocol"
 )
 
 func ExampleIPing_Trace() {
@@ -18,7 +19,7 @@ func ExampleIPing_Trace() {
 	addr, _ := net.ResolveIPAddr("ip", "example.com")
 	ttl := 1
 	for {
-		if latency, from, err := ping.Trace(addr, ttl, 2*time.Second); err != nil {
+		if latency, from, err := ping.Trace(addr, protocol.Origin{}, ttl, 2*time.Second); err != nil {
 			if _, ok := err.(*errors.ErrTTLExceed); !ok {
 				log.Println("timeout")
 				break
@@ -1,22 +1,164 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/jackpal/gateway"
+	"github.com/yittg/ving/net/netns"
 )
 
+// Origin where probes are sent from, zero value for default
+type Origin struct {
+	// Source address to send probes from, nil for default
+	Source net.IP
+	// Netns is the path of network namespace to open sockets in, empty for the current one
+	Netns string
+}
+
+// IsDefault represents whether probes are sent from default source in the current namespace
+func (o Origin) IsDefault() bool {
+	return o.Source == nil && o.Netns == ""
+}
+
 // NetworkTarget represents network target resolved
 type NetworkTarget struct {
 	Typ    TargetType
 	Raw    string
 	Target interface{}
+
+	// Name to display instead of Raw if not empty
+	Name  string
+	Group string
+	Tags  []string
+
+	// Origin to send probes from
+	Origin
+}
+
+// Title to display
+func (t *NetworkTarget) Title() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Raw
+}
+
+// Address of the target resolved, like 10.0.0.1 or 10.0.0.1:80, empty if unknown
+func (t *NetworkTarget) Address() string {
+	switch addr := t.Target.(type) {
+	case *net.IPAddr:
+		return addr.String()
+	case *net.TCPAddr:
+		return addr.String()
+	default:
+		return ""
+	}
+}
+
+// Family of the address resolved, 4 or 6, 0 if unknown
+func (t *NetworkTarget) Family() int {
+	var ip net.IP
+	switch addr := t.Target.(type) {
+	case *net.IPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	default:
+		return 0
+	}
+	if ip.To4() != nil {
+		return 4
+	}
+	return 6
+}
+
+// Bind the target to send probes from the interface or source address `bind` in its network namespace,
+// it turns unknown if no address of its family to bind, nothing changed if `bind` is empty
+func (t *NetworkTarget) Bind(bind string) *NetworkTarget {
+	if bind == "" || t.Typ == Unknown {
+		return t
+	}
+	var source net.IP
+	err := netns.Do(t.Netns, func() (err error) {
+		source, err = SourceOf(bind, t.Family())
+		return
+	})
+	if err != nil {
+		return &NetworkTarget{
+			Typ:    Unknown,
+			Raw:    t.Raw,
+			Target: err,
+			Name:   t.Name,
+			Group:  t.Group,
+			Tags:   t.Tags,
+			Origin: Origin{Netns: t.Netns},
+		}
+	}
+	t.Source = source
+	return t
+}
+
+// SourceOf the binding in the family 4 or 6, which is an IP, or an interface whose first address of the family is used
+func SourceOf(bind string, family int) (net.IP, error) {
+	if ip := net.ParseIP(bind); ip != nil {
+		if (ip.To4() != nil) != (family == 4) {
+			return nil, fmt.Errorf("source address %s is not of IPv%d", bind, family)
+		}
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(bind)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() != nil) != (family == 4) {
+			continue
+		}
+		// link-local IPv6 addresses need a zone to bind, prefer others
+		if family == 6 && ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		return ipNet.IP, nil
+	}
+	return nil, fmt.Errorf("no IPv%d address on interface %s", family, bind)
+}
+
+// IPNetwork of the address family, ip4 for 4, ip6 for 6, or ip for any
+func IPNetwork(family int) string {
+	switch family {
+	case 4:
+		return "ip4"
+	case 6:
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+// IsHostname represents whether the address is a hostname rather than an IP
+func IsHostname(address string) bool {
+	if i := strings.IndexByte(address, '%'); i >= 0 {
+		address = address[:i]
+	}
+	return net.ParseIP(address) == nil
 }
 
 // ResolveTarget as NetworkTarget
 func ResolveTarget(target string) *NetworkTarget {
-	ipTarget, e := resolveIPTarget(target)
+	return ResolveFamilyTarget(target, 0)
+}
+
+// ResolveFamilyTarget as NetworkTarget with address in the family, 4 or 6, or any if 0
+func ResolveFamilyTarget(target string, family int) *NetworkTarget {
+	ipTarget, e := resolveIPTarget(IPNetwork(family), target)
 	if e != nil {
 		return &NetworkTarget{
 			Typ:    Unknown,
@@ -27,8 +169,43 @@ func ResolveTarget(target string) *NetworkTarget {
 	return ipTarget
 }
 
-func resolveIPTarget(address string) (*NetworkTarget, error) {
-	ipAddr, err := net.ResolveIPAddr("ip", address)
+// ResolveTCPTarget resolves address like host:port as tcp target, in the family 4 or 6, or any if 0
+func ResolveTCPTarget(address string, family int) *NetworkTarget {
+	network := "tcp"
+	if family == 4 || family == 6 {
+		network = fmt.Sprintf("tcp%d", family)
+	}
+	tcpAddr, err := net.ResolveTCPAddr(network, address)
+	if err != nil {
+		return &NetworkTarget{
+			Typ:    Unknown,
+			Raw:    address,
+			Target: err,
+		}
+	}
+	return &NetworkTarget{
+		Typ:    TCP,
+		Raw:    address,
+		Target: tcpAddr,
+	}
+}
+
+// LookupIPs of the host in the family 4 or 6, or any if 0
+func LookupIPs(host string, family int) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(context.Background(), IPNetwork(family), host)
+}
+
+// IPTarget of the address resolved from `raw`
+func IPTarget(raw string, ip net.IP) *NetworkTarget {
+	return &NetworkTarget{
+		Typ:    IP,
+		Raw:    raw,
+		Target: &net.IPAddr{IP: ip},
+	}
+}
+
+func resolveIPTarget(network, address string) (*NetworkTarget, error) {
+	ipAddr, err := net.ResolveIPAddr(network, address)
 	if err != nil {
 		return nil, err
 	}
@@ -63,5 +240,6 @@ func TCPTarget(networkTarget *NetworkTarget, port int) *NetworkTarget {
 		Typ:    TCP,
 		Raw:    fmt.Sprintf("%s:%d", networkTarget.Raw, port),
 		Target: &net.TCPAddr{IP: addr.IP, Port: port, Zone: addr.Zone},
+		Origin: networkTarget.Origin,
 	}
 }
@@ -2,9 +2,16 @@ package tcp
 
 import (
 	"net"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/yittg/ving/net/netns"
+	"github.com/yittg/ving/net/protocol"
 )
 
+const maxBannerSize = 256
+
 // TPing provide ability to connect to tcp port
 type TPing struct {
 }
@@ -14,14 +21,52 @@ func NewPing() *TPing {
 	return &TPing{}
 }
 
-// Touch a tcp addr
-func (p *TPing) Touch(addr *net.TCPAddr, timeout time.Duration) (time.Duration, error) {
-	dialAt := time.Now()
-	conn, err := net.DialTimeout("tcp", addr.String(), timeout)
-	dialDoneAt := time.Now()
+// Touch a tcp addr from the origin
+func (p *TPing) Touch(addr *net.TCPAddr, origin protocol.Origin, timeout time.Duration) (time.Duration, error) {
+	latency, _, err := p.Grab(addr, origin, timeout, 0)
+	return latency, err
+}
+
+// Grab connects to a tcp addr from the origin, and reads what the server sends in `wait` as banner
+func (p *TPing) Grab(addr *net.TCPAddr, origin protocol.Origin, timeout, wait time.Duration) (time.Duration, string, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	if origin.Source != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: origin.Source}
+	}
+	var conn net.Conn
+	var dialAt, dialDoneAt time.Time
+	err := netns.Do(origin.Netns, func() (err error) {
+		dialAt = time.Now()
+		conn, err = dialer.Dial("tcp", addr.String())
+		dialDoneAt = time.Now()
+		return
+	})
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 	defer conn.Close()
-	return dialDoneAt.Sub(dialAt), nil
+	latency := dialDoneAt.Sub(dialAt)
+	if wait <= 0 {
+		return latency, "", nil
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return latency, "", nil
+	}
+	buf := make([]byte, maxBannerSize)
+	n, _ := conn.Read(buf)
+	return latency, sanitizeBanner(buf[:n]), nil
+}
+
+// sanitizeBanner keeps the first line of banner and drops unprintable characters
+func sanitizeBanner(b []byte) string {
+	line := string(b)
+	if i := strings.IndexAny(line, "\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, line)
 }
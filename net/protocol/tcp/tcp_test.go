@@ -4,17 +4,19 @@ import (
 	"log"
 	"net"
 	"time"
+
+	"github.com/yittg/ving/net/protocol"
 )
 
 func Example() {
-	ping := NewPing(nil)
+	ping := NewPing()
 
 	addrHTTP, _ := net.ResolveTCPAddr("tcp", "example.com:80")
 	addrHTTPS, _ := net.ResolveTCPAddr("tcp", "example.com:443")
 
 	addrArr := []*net.TCPAddr{addrHTTP, addrHTTPS}
 	for _, addr := range addrArr {
-		duration, err := ping.Touch(addr, time.Second*2)
+		duration, err := ping.Touch(addr, protocol.Origin{}, time.Second*2)
 		if err != nil {
 			log.Fatalf("touch %s error, %s", addr.String(), err)
 		}
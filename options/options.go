@@ -10,6 +10,9 @@ import (
 	flag "github.com/spf13/pflag"
 	"github.com/yittg/ving/config"
 	"github.com/yittg/ving/errors"
+	"github.com/yittg/ving/net/netns"
+	"github.com/yittg/ving/statistic"
+	targetconfig "github.com/yittg/ving/targets/config"
 	"github.com/yittg/ving/utils/slices"
 )
 
@@ -17,29 +20,72 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, `Usage: %s [options] target [target...]
 for example: %s 127.0.0.1 192.168.0.1
              %s -i 100ms 192.168.0.1
-`, slices.Repeat(os.Args[0], 3)...)
+             %s --no-ui --ports-check 10.0.0.1 10.0.0.2
+             %s 10.0.1.0/28 10.0.2.10-20
+             %s -f targets.txt
+             %s @prod
+       %s history [options] target [target...]
+`, slices.Repeat(os.Args[0], 8)...)
 	flag.PrintDefaults()
 }
 
 // Option represents options provided
 type Option struct {
 	Interval time.Duration
-	Timeout  time.Duration
+	// Jitter of interval as a fraction of it
+	Jitter  float64
+	Burst   int
+	Timeout time.Duration
 
-	Gateway      bool
-	Trace        bool
-	Ports        bool
-	MorePortsStr []string
-	MorePorts    []int
+	TargetsFile string
+	Groups      []string
 
-	Sort bool
+	ResolveInterval time.Duration
+	AllAddresses    bool
+	DualStack       bool
+	IPv4            bool
+	IPv6            bool
+	// Family of addresses preferred, 4 or 6, or 0 for any
+	Family int
+	// Interface or source address to send probes from
+	Interface string
+	// Netns is the path of network namespace to send probes from, by name or path, or of process NetnsPid
+	Netns    string
+	NetnsPid int
+
+	Gateway           bool
+	Trace             bool
+	Ports             bool
+	PortsMatrix       bool
+	MorePortsStr      []string
+	MorePorts         []int
+	PortProfile       string
+	PortsRate         float64
+	PortsTargetRate   float64
+	PortsExport       string
+	PortsExportFormat string
+	PortsCheck        bool
+
+	NoUI     bool
+	Duration time.Duration
+
+	Sort         bool
+	SortBy       string
+	SortDesc     bool
+	SortStrategy statistic.SortStrategy
+
+	MOS bool
+
+	Control string
 
 	ShowVersion bool
 }
 
 func (o *Option) interalValid() bool {
 	statisticWindow := config.GetConfig().Statistic.Window.Value
-	return o.Interval >= 10*time.Millisecond && o.Interval < statisticWindow
+	return o.Interval >= 10*time.Millisecond && targetconfig.MaxInterval(o.Interval, o.Jitter) < statisticWindow &&
+		o.Jitter >= 0 && o.Jitter < 1 &&
+		o.Burst >= 1 && o.Burst <= targetconfig.MaxBurst
 }
 
 func allPortNumber(strs ...string) ([]int, error) {
@@ -82,25 +128,136 @@ func (o *Option) portsValid() bool {
 	return true
 }
 
+func (o *Option) portProfileValid() bool {
+	return o.PortProfile == "" || config.GetConfig().AddOns.Ports.HasProfile(o.PortProfile)
+}
+
+func (o *Option) portsCheckValid() bool {
+	return !o.PortsCheck || len(config.GetConfig().AddOns.Ports.Policies) > 0
+}
+
+func (o *Option) sortValid() bool {
+	if o.SortBy == "" {
+		if o.Sort {
+			o.SortStrategy = statistic.Default
+		} else {
+			o.SortStrategy = statistic.ByInput
+		}
+		return true
+	}
+	strategy, err := statistic.ParseSortStrategy(o.SortBy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	o.SortStrategy = strategy
+	return true
+}
+
+func (o *Option) familyValid() bool {
+	return !(o.IPv4 && o.IPv6) && (!o.DualStack || !o.AllAddresses && o.Family == 0)
+}
+
+// familyOf addresses preferred by -4 or -6, or in configuration
+func (o *Option) familyOf() int {
+	switch {
+	case o.IPv4:
+		return 4
+	case o.IPv6:
+		return 6
+	default:
+		return config.GetConfig().Resolve.Family
+	}
+}
+
+func (o *Option) netnsValid() bool {
+	if o.NetnsPid != 0 {
+		if o.Netns != "" || o.NetnsPid < 0 {
+			return false
+		}
+		o.Netns = netns.PathOfPid(o.NetnsPid)
+		return true
+	}
+	o.Netns = netns.Path(o.Netns)
+	return true
+}
+
 func (o *Option) isValid() bool {
 	return o.interalValid() &&
+		(o.ResolveInterval == 0 || o.ResolveInterval >= time.Second) &&
+		o.familyValid() &&
+		o.netnsValid() &&
 		o.Timeout >= 10*time.Millisecond &&
-		o.portsValid()
+		o.portsValid() &&
+		o.portProfileValid() &&
+		o.portsCheckValid() &&
+		o.Duration >= 0 &&
+		o.sortValid() &&
+		o.PortsRate >= 0 && o.PortsTargetRate >= 0 &&
+		slices.ContainStr([]string{"", "json", "csv", "grep"}, o.PortsExportFormat)
 }
 
 // ParseCommandLine results options and targets
 func ParseCommandLine(opt *Option) []string {
 	flag.Usage = printUsage
-	flag.DurationVarP(&opt.Interval, "interval", "i", time.Second, `ping interval, should be shorter than statistic window, must >=10ms`)
+	flag.DurationVarP(&opt.Interval, "interval", "i", time.Second, `ping interval, should be shorter than statistic window even with jitter, must >=10ms`)
+	flag.Float64VarP(&opt.Jitter, "jitter", "", 0,
+		"random jitter of interval as a fraction of it, e.g. 0.1 for ±10%, to avoid synchronized bursts")
+	flag.IntVarP(&opt.Burst, "burst", "", 1,
+		fmt.Sprintf("pings sent concurrently each interval to estimate loss faster, at most %d", targetconfig.MaxBurst))
 	flag.DurationVarP(&opt.Timeout, "timeout", "t", time.Second, "ping timeout, must >=10ms")
+	flag.StringVarP(&opt.TargetsFile, "file", "f", "",
+		"read targets from the file, one per line like \"10.0.1.1 name=web-1 tags=prod,dc1\", - for stdin")
+	flag.StringArrayVarP(&opt.Groups, "group", "", []string{},
+		"ping targets in the group defined in configuration, the same as argument @group, can be repeated")
+	resolveConfig := config.GetConfig().Resolve
+	flag.DurationVarP(&opt.ResolveInterval, "resolve-interval", "", resolveConfig.Interval.Value,
+		"re-resolve hostnames periodically and follow address changes, must >=1s, 0 means never")
+	flag.BoolVarP(&opt.AllAddresses, "all-addresses", "", resolveConfig.AllAddresses,
+		"ping every A/AAAA address of hostnames as sub-targets, instead of the first one")
+	flag.BoolVarP(&opt.DualStack, "dual-stack", "", resolveConfig.DualStack,
+		"ping both IPv4 and IPv6 addresses of hostnames side by side, exclusive with --all-addresses, -4 and -6")
+	flag.BoolVarP(&opt.IPv4, "ipv4", "4", false, "resolve hostnames to IPv4 addresses only")
+	flag.BoolVarP(&opt.IPv6, "ipv6", "6", false, "resolve hostnames to IPv6 addresses only")
+	flag.StringVarP(&opt.Interface, "interface", "I", "",
+		"interface or source address to send probes from, e.g. eth1 or 10.0.0.2, overridden by targets defined")
+	flag.StringVarP(&opt.Netns, "netns", "", "",
+		"network namespace to send probes from, by name of ip netns or path like /var/run/netns/foo")
+	flag.IntVarP(&opt.NetnsPid, "netns-pid", "", 0, "send probes from the network namespace of the process")
 	flag.BoolVarP(&opt.Gateway, "gateway", "g", false, "ping gateway")
 	flag.BoolVarP(&opt.Trace, "trace", "T", false, "automatically traceroute the target")
 	flag.BoolVarP(&opt.Ports, "ports", "", false, "automatically probe the target ports")
+	flag.BoolVarP(&opt.PortsMatrix, "ports-matrix", "", false, "automatically probe ports of all targets, shown as a matrix")
 	flag.StringArrayVarP(&opt.MorePortsStr, "more-ports", "P", []string{},
 		"ports to probe, e.g. -P 8080 -P 8082-8092")
-	flag.BoolVarP(&opt.Sort, "sort", "", false, "sort by statistic")
+	flag.StringVarP(&opt.PortProfile, "port-profile", "", "",
+		"named ports profile to probe, e.g. default, web, databases, k8s, or defined in configuration")
+	portsConfig := config.GetConfig().AddOns.Ports
+	flag.Float64VarP(&opt.PortsRate, "ports-rate", "", portsConfig.Rate,
+		"max ports probe packets per second in total, 0 means unlimited")
+	flag.Float64VarP(&opt.PortsTargetRate, "ports-target-rate", "", portsConfig.TargetRate,
+		"max ports probe packets per second to a single target, 0 means unlimited")
+	flag.StringVarP(&opt.PortsExport, "ports-export", "", "",
+		"export ports probe results of all targets into the file after done, e.g. ports.json, ports.csv, ports.gnmap")
+	flag.StringVarP(&opt.PortsExportFormat, "ports-export-format", "", "",
+		"format of ports export, json, csv or grep, decided by the extension of export file by default")
+	flag.BoolVarP(&opt.PortsCheck, "ports-check", "", false,
+		"probe ports of all targets and check against policies in configuration, exit with 3 if violated")
+	flag.BoolVarP(&opt.NoUI, "no-ui", "", false,
+		"run without terminal ui, print the report after finished, interrupted, or duration elapsed")
+	flag.DurationVarP(&opt.Duration, "duration", "", 0, "stop after the duration, 0 means no limit")
+	flag.BoolVarP(&opt.Sort, "sort", "", false, "sort by statistic, the same as --sort-by default")
+	uiConfig := config.GetConfig().UI
+	flag.StringVarP(&opt.SortBy, "sort-by", "", uiConfig.SortBy,
+		fmt.Sprintf("sort strategy, one of %s, input by default", strings.Join(statistic.SortStrategyNames(), ", ")))
+	flag.BoolVarP(&opt.SortDesc, "sort-desc", "", uiConfig.SortDesc, "sort in descending order")
+	flag.BoolVarP(&opt.MOS, "mos", "", uiConfig.MOS,
+		"show voice quality as MOS and R factor of ITU-T G.107 E-model, instead of error rate")
+	flag.StringVarP(&opt.Control, "control", "", "",
+		"listen on the unix socket for control commands, mark <note>, add|remove <target>, pause|resume|step [target]")
 	flag.BoolVarP(&opt.ShowVersion, "version", "v", false, "display the version")
 	flag.Parse()
+	opt.Family = opt.familyOf()
 
 	if !opt.isValid() {
 		flag.Usage()
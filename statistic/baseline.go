@@ -0,0 +1,122 @@
+package statistic
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// madScale makes MAD a consistent estimator of standard deviation for normal distribution
+const madScale = 1.4826
+
+// baseline of latency learned from EWMA of median and MAD of batches of successful records
+type baseline struct {
+	median  float64
+	mad     float64
+	batches int
+	batch   []int64
+}
+
+// learn a successful cost, folds the batch into baseline when it's full
+func (b *baseline) learn(cost int64) {
+	b.batch = append(b.batch, cost)
+	if len(b.batch) < statisticConfig.BaselineBatch {
+		return
+	}
+	median, mad := medianAndMAD(b.batch)
+	b.batch = b.batch[:0]
+	if b.batches == 0 {
+		b.median, b.mad = median, mad
+	} else {
+		alpha := statisticConfig.BaselineAlpha
+		b.median = (1-alpha)*b.median + alpha*median
+		b.mad = (1-alpha)*b.mad + alpha*mad
+	}
+	b.batches++
+}
+
+// ready represents whether the baseline learned enough batches to judge
+func (b *baseline) ready() bool {
+	return b.batches >= statisticConfig.BaselineWarmup
+}
+
+// deviation of baseline, not less than the configured minimum deviation
+func (b *baseline) deviation() float64 {
+	return math.Max(madScale*b.mad, float64(statisticConfig.AnomalyMinDeviation.Value))
+}
+
+// score how many deviations `v` is above the baseline median, 0 if not ready
+func (b *baseline) score(v float64) float64 {
+	if !b.ready() {
+		return 0
+	}
+	return (v - b.median) / b.deviation()
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func sortedFloats(values []int64) []float64 {
+	sorted := make([]float64, len(values))
+	for i, v := range values {
+		sorted[i] = float64(v)
+	}
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// medianAndMAD calculates median and median absolute deviation of values
+func medianAndMAD(values []int64) (float64, float64) {
+	sorted := sortedFloats(values)
+	m := median(sorted)
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = math.Abs(v - m)
+	}
+	sort.Float64s(deviations)
+	return m, median(deviations)
+}
+
+// Baseline represents the learned median and deviation of latency, ok is false if still learning
+func (s *Detail) Baseline() (median, deviation time.Duration, ok bool) {
+	if !s.baseline.ready() {
+		return 0, 0, false
+	}
+	return time.Duration(s.baseline.median), time.Duration(s.baseline.deviation()), true
+}
+
+// updateAnomaly scores the successful `cost` and median of window against baseline, then learns the cost
+func (s *Detail) updateAnomaly(cost int64) {
+	s.sampleScore = s.baseline.score(float64(cost))
+	s.windowMedian = median(sortedFloats(s.lastSuccessfulCosts()))
+	s.windowScore = s.baseline.score(s.windowMedian)
+	s.baseline.learn(cost)
+}
+
+func anomalous(score float64) bool {
+	thresh := statisticConfig.AnomalyThresh
+	return thresh > 0 && score > thresh
+}
+
+// Anomalous represents the median latency in window deviates significantly from baseline
+func (s *Detail) Anomalous() bool {
+	return anomalous(s.windowScore)
+}
+
+// LastSampleAnomalous represents the latest successful latency deviates significantly from baseline
+func (s *Detail) LastSampleAnomalous() bool {
+	return anomalous(s.sampleScore)
+}
+
+// WindowMedianCost represents median latency in window, and its score of deviations from baseline
+func (s *Detail) WindowMedianCost() (time.Duration, float64) {
+	return time.Duration(s.windowMedian), s.windowScore
+}
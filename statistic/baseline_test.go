@@ -0,0 +1,50 @@
+package statistic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestMedianAndMAD(t *testing.T) {
+	m, mad := medianAndMAD([]int64{1, 1, 2, 2, 4, 6, 9})
+	if m != 2 || mad != 1 {
+		t.Errorf("expect median 2 and MAD 1, but got %v and %v", m, mad)
+	}
+	m, mad = medianAndMAD([]int64{4, 1, 3, 2})
+	if m != 2.5 || mad != 1 {
+		t.Errorf("expect median 2.5 and MAD 1, but got %v and %v", m, mad)
+	}
+}
+
+func TestAnomalyAgainstBaseline(t *testing.T) {
+	d := &Detail{Cost: make([]int, 1)}
+	now := time.Now()
+	deal := func(cost time.Duration) {
+		now = now.Add(time.Second)
+		d.DealRecord(now, types.Record{Successful: true, Cost: cost})
+		d.RetireRecord(now)
+	}
+	jitters := []time.Duration{-time.Millisecond, 0, time.Millisecond}
+	for i := 0; i < 60; i++ {
+		deal(40*time.Millisecond + jitters[i%len(jitters)])
+	}
+	if median, _, ok := d.Baseline(); !ok || median != 40*time.Millisecond {
+		t.Fatalf("expect baseline learned as 40ms, but got %v, %v", median, ok)
+	}
+	if d.Anomalous() || d.LastSampleAnomalous() {
+		t.Fatal("expect no anomaly for stable latency")
+	}
+
+	deal(80 * time.Millisecond)
+	if !d.LastSampleAnomalous() || d.Anomalous() {
+		t.Fatal("expect the sample anomalous, but not the window")
+	}
+	for i := 0; i < 10; i++ {
+		deal(80 * time.Millisecond)
+	}
+	if !d.Anomalous() {
+		t.Fatal("expect the window anomalous")
+	}
+}
@@ -0,0 +1,165 @@
+package statistic
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// minSignificantSamples is the least samples of each side to test significance
+const minSignificantSamples = 8
+
+// significanceLevel of p-value
+const significanceLevel = 0.05
+
+// Sample of records in a range of time
+type Sample struct {
+	// Costs of successful records, in ascending order
+	Costs  []int64
+	Total  int
+	Errors int
+}
+
+// SampleBetween returns the sample of records in [from, to), limited to records retained in the longest window
+func (s *Detail) SampleBetween(from, to time.Time) Sample {
+	var sample Sample
+	for _, r := range s.records {
+		if r.T.Before(from) || !r.T.Before(to) {
+			continue
+		}
+		sample.Total++
+		if r.Record.Successful {
+			sample.Costs = append(sample.Costs, int64(r.Record.Cost))
+		} else {
+			sample.Errors++
+		}
+	}
+	sort.Slice(sample.Costs, func(i, j int) bool { return sample.Costs[i] < sample.Costs[j] })
+	return sample
+}
+
+// LossRate of the sample
+func (s *Sample) LossRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Total)
+}
+
+// Percentile of latency, math.MaxInt64 if no successful records
+func (s *Sample) Percentile(p float64) int64 {
+	if len(s.Costs) == 0 {
+		return math.MaxInt64
+	}
+	idx := int(math.Ceil(p/100*float64(len(s.Costs)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return s.Costs[idx]
+}
+
+// Histogram of latency of the sample
+func (s *Sample) Histogram() Histogram {
+	h := newHistogram()
+	for _, cost := range s.Costs {
+		h[BucketOf(time.Duration(cost))]++
+	}
+	return h
+}
+
+// Significance of difference between two samples
+type Significance struct {
+	// P value, 1 if not tested
+	P float64
+	// Tested if there are enough samples
+	Tested bool
+	// Greater represents whether the second sample is greater
+	Greater bool
+}
+
+// Significant represents whether the difference is statistically significant
+func (s Significance) Significant() bool {
+	return s.Tested && s.P < significanceLevel
+}
+
+// Comparison between two samples
+type Comparison struct {
+	Latency Significance
+	Loss    Significance
+}
+
+// twoSidedP of standard normal z score
+func twoSidedP(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// mannWhitney tests whether latency of the two samples differs, by Mann-Whitney U test with normal approximation
+func mannWhitney(a, b []int64) Significance {
+	n1, n2 := len(a), len(b)
+	if n1 < minSignificantSamples || n2 < minSignificantSamples {
+		return Significance{P: 1}
+	}
+	type ranked struct {
+		v     int64
+		first bool
+	}
+	all := make([]ranked, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range b {
+		all = append(all, ranked{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// ranks of ties are averaged, and ties correct the variance
+	r1, tieCorrection := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		t := float64(j - i)
+		tieCorrection += t*t*t - t
+		i = j
+	}
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u1 := r1 - fn1*(fn1+1)/2
+	mu := fn1 * fn2 / 2
+	sigma := math.Sqrt(fn1 * fn2 / 12 * ((n + 1) - tieCorrection/(n*(n-1))))
+	if sigma == 0 {
+		return Significance{P: 1, Tested: true}
+	}
+	z := (u1 - mu) / sigma
+	return Significance{P: twoSidedP(z), Tested: true, Greater: u1 < mu}
+}
+
+// twoProportions tests whether loss rate of the two samples differs, by two proportions z test
+func twoProportions(a, b *Sample) Significance {
+	if a.Total < minSignificantSamples || b.Total < minSignificantSamples {
+		return Significance{P: 1}
+	}
+	n1, n2 := float64(a.Total), float64(b.Total)
+	p1, p2 := a.LossRate(), b.LossRate()
+	p := float64(a.Errors+b.Errors) / (n1 + n2)
+	se := math.Sqrt(p * (1 - p) * (1/n1 + 1/n2))
+	if se == 0 {
+		return Significance{P: 1, Tested: true}
+	}
+	return Significance{P: twoSidedP((p1 - p2) / se), Tested: true, Greater: p2 > p1}
+}
+
+// Compare two samples
+func Compare(a, b *Sample) Comparison {
+	return Comparison{
+		Latency: mannWhitney(a.Costs, b.Costs),
+		Loss:    twoProportions(a, b),
+	}
+}
@@ -0,0 +1,35 @@
+package statistic
+
+import (
+	"testing"
+)
+
+func TestMannWhitney(t *testing.T) {
+	var a, b, c []int64
+	for i := 0; i < 50; i++ {
+		a = append(a, int64(40+i%5))
+		b = append(b, int64(44+i%5))
+		c = append(c, int64(40+(i+2)%5))
+	}
+	if s := mannWhitney(a, b); !s.Significant() || !s.Greater {
+		t.Errorf("expect b significantly greater than a, but got %+v", s)
+	}
+	if s := mannWhitney(a, c); s.Significant() {
+		t.Errorf("expect no significant difference between a and c, but got %+v", s)
+	}
+	if s := mannWhitney(a[:3], b[:3]); s.Tested {
+		t.Errorf("expect not tested for few samples, but got %+v", s)
+	}
+}
+
+func TestTwoProportions(t *testing.T) {
+	a := &Sample{Total: 1000, Errors: 10}
+	b := &Sample{Total: 1000, Errors: 50}
+	if s := twoProportions(a, b); !s.Significant() || !s.Greater {
+		t.Errorf("expect loss of b significantly greater than a, but got %+v", s)
+	}
+	c := &Sample{Total: 1000, Errors: 12}
+	if s := twoProportions(a, c); s.Significant() {
+		t.Errorf("expect no significant difference of loss, but got %+v", s)
+	}
+}
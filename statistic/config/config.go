@@ -13,6 +13,19 @@ type Config struct {
 	ErrorRateThresh  []float64  `toml:"error-rate-thresh"`
 	LowLatencyThresh c.Duration `toml:"low-latency-thresh"`
 	Window           c.Duration
+	// Windows tracked besides the primary `Window`, e.g. to tell a momentary blip from a sustained degradation
+	Windows []c.Duration `toml:"windows"`
+
+	// AnomalyThresh is how many deviations above the learned baseline is anomalous, 0 means disabled
+	AnomalyThresh float64 `toml:"anomaly-thresh"`
+	// AnomalyMinDeviation avoids flagging tiny jitters of very stable targets
+	AnomalyMinDeviation c.Duration `toml:"anomaly-min-deviation"`
+	// BaselineAlpha is the EWMA smoothing factor of baseline
+	BaselineAlpha float64 `toml:"baseline-alpha"`
+	// BaselineBatch is count of successful records folded into baseline at once
+	BaselineBatch int `toml:"baseline-batch"`
+	// BaselineWarmup is count of batches learned before judging
+	BaselineWarmup int `toml:"baseline-warmup"`
 }
 
 // Validate the statistic config
@@ -37,6 +50,35 @@ func (c *Config) Validate() error {
 			Msg: fmt.Sprintf("invalid statistic window, should longer than 1s, (window=%v)", c.Window),
 		}
 	}
+	for _, w := range c.Windows {
+		if w.Value < time.Second {
+			return &errors.ConfigError{
+				Msg: fmt.Sprintf("invalid statistic windows, should longer than 1s, (windows=%v)", c.Windows),
+			}
+		}
+	}
+	if c.AnomalyThresh < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("anomaly threshold should not be negative, (anomaly-thresh=%v)", c.AnomalyThresh),
+		}
+	}
+	if c.AnomalyMinDeviation.Value <= 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("anomaly minimum deviation should be positive, (anomaly-min-deviation=%v)",
+				c.AnomalyMinDeviation.Value),
+		}
+	}
+	if c.BaselineAlpha <= 0 || c.BaselineAlpha > 1 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("baseline alpha should be in (0, 1], (baseline-alpha=%v)", c.BaselineAlpha),
+		}
+	}
+	if c.BaselineBatch <= 0 || c.BaselineWarmup <= 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("baseline batch and warmup should be positive, (baseline-batch=%d, baseline-warmup=%d)",
+				c.BaselineBatch, c.BaselineWarmup),
+		}
+	}
 	return nil
 }
 
@@ -50,5 +92,16 @@ func Default() Config {
 		Window: c.Duration{
 			Value: 10 * time.Second,
 		},
+		Windows: []c.Duration{
+			{Value: time.Minute},
+			{Value: 15 * time.Minute},
+		},
+		AnomalyThresh: 5,
+		AnomalyMinDeviation: c.Duration{
+			Value: time.Millisecond,
+		},
+		BaselineAlpha:  0.1,
+		BaselineBatch:  10,
+		BaselineWarmup: 3,
 	}
 }
@@ -0,0 +1,63 @@
+package statistic
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// peerBrokenErrRate is the error rate in window from which a family is broken, if its peer is not
+	peerBrokenErrRate = 0.5
+	// peerSlowerRatio and peerSlowerGap, a family is significantly slower than its peer
+	// if its average latency in window is above the peer's by both
+	peerSlowerRatio = 1.5
+	peerSlowerGap   = 5 * time.Millisecond
+)
+
+// PeerVerdict of a target against its peer of the other address family in dual-stack mode
+type PeerVerdict int
+
+// Verdicts against the peer
+const (
+	PeerFine PeerVerdict = iota
+	PeerBroken
+	PeerSlower
+)
+
+var peerVerdictNames = []string{
+	PeerFine:   "fine",
+	PeerBroken: "broken",
+	PeerSlower: "slower",
+}
+
+func (v PeerVerdict) String() string {
+	return peerVerdictNames[v]
+}
+
+// AgainstPeer compares the target with its peer in window, fine if no peer
+func (s *Detail) AgainstPeer() PeerVerdict {
+	p := s.Peer
+	if p == nil {
+		return PeerFine
+	}
+	if s.LastErrRate() >= peerBrokenErrRate && p.LastErrRate() < peerBrokenErrRate {
+		return PeerBroken
+	}
+	if ratio := s.PeerLatencyRatio(); ratio > peerSlowerRatio &&
+		s.LastAverageCost()-p.LastAverageCost() > int64(peerSlowerGap) {
+		return PeerSlower
+	}
+	return PeerFine
+}
+
+// PeerLatencyRatio of average latency in window to the peer's, 0 if unknown
+func (s *Detail) PeerLatencyRatio() float64 {
+	if s.Peer == nil {
+		return 0
+	}
+	own, peer := s.LastAverageCost(), s.Peer.LastAverageCost()
+	if own == math.MaxInt64 || peer == math.MaxInt64 || peer == 0 {
+		return 0
+	}
+	return float64(own) / float64(peer)
+}
@@ -0,0 +1,36 @@
+package statistic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestAgainstPeer(t *testing.T) {
+	now := time.Now()
+	deal := func(st *Detail, n int, cost time.Duration, successful bool) {
+		for i := 0; i < n; i++ {
+			st.DealRecord(now, types.Record{Successful: successful, Cost: cost})
+		}
+	}
+	v4 := &Detail{Cost: make([]int, 1), Family: 4}
+	v6 := &Detail{Cost: make([]int, 1), Family: 6}
+	if v6.AgainstPeer() != PeerFine {
+		t.Errorf("expect fine without peer")
+	}
+	v4.Peer, v6.Peer = v6, v4
+	deal(v4, 10, 10*time.Millisecond, true)
+	deal(v6, 10, 12*time.Millisecond, true)
+	if v6.AgainstPeer() != PeerFine {
+		t.Errorf("expect v6 fine if slightly slower")
+	}
+	deal(v6, 20, 60*time.Millisecond, true)
+	if v6.AgainstPeer() != PeerSlower || v4.AgainstPeer() != PeerFine {
+		t.Errorf("expect v6 slower, ratio %.1f", v6.PeerLatencyRatio())
+	}
+	deal(v6, 40, 0, false)
+	if v6.AgainstPeer() != PeerBroken {
+		t.Errorf("expect v6 broken")
+	}
+}
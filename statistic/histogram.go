@@ -0,0 +1,124 @@
+package statistic
+
+import (
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+// maxHeatmapSlots of the session, adjacent slots are merged when exceeded
+const maxHeatmapSlots = 256
+
+// initialHeatmapSlot is the size of heatmap slot initially, doubled when slots merged
+const initialHeatmapSlot = time.Second
+
+// LatencyBuckets are upper bounds of latency histogram buckets in log scale,
+// the last bucket holds those beyond the last bound
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	300 * time.Microsecond,
+	time.Millisecond,
+	3 * time.Millisecond,
+	10 * time.Millisecond,
+	30 * time.Millisecond,
+	100 * time.Millisecond,
+	300 * time.Millisecond,
+	time.Second,
+	3 * time.Second,
+}
+
+// Histogram counts records of each latency bucket
+type Histogram []int
+
+func newHistogram() Histogram {
+	return make(Histogram, len(LatencyBuckets)+1)
+}
+
+// BucketOf the latency
+func BucketOf(cost time.Duration) int {
+	for i, bound := range LatencyBuckets {
+		if cost < bound {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+// Total count of records in histogram
+func (h Histogram) Total() int {
+	total := 0
+	for _, count := range h {
+		total += count
+	}
+	return total
+}
+
+func (h Histogram) merge(other Histogram) {
+	for i, count := range other {
+		h[i] += count
+	}
+}
+
+// HeatmapSlot holds the latency histogram and errors in a slot of time
+type HeatmapSlot struct {
+	Histogram Histogram
+	Errors    int
+}
+
+// heatmap of latency over the session
+type heatmap struct {
+	start    time.Time
+	slotSize time.Duration
+	slots    []HeatmapSlot
+}
+
+func (h *heatmap) add(t time.Time, record types.Record) {
+	if h.slots == nil {
+		h.start = t
+		h.slotSize = initialHeatmapSlot
+	}
+	idx := int(t.Sub(h.start) / h.slotSize)
+	for idx >= maxHeatmapSlots {
+		h.mergeSlots()
+		idx = int(t.Sub(h.start) / h.slotSize)
+	}
+	for len(h.slots) <= idx {
+		h.slots = append(h.slots, HeatmapSlot{Histogram: newHistogram()})
+	}
+	if record.Successful {
+		h.slots[idx].Histogram[BucketOf(record.Cost)]++
+	} else {
+		h.slots[idx].Errors++
+	}
+}
+
+// mergeSlots merges adjacent slots, the size of slot doubled
+func (h *heatmap) mergeSlots() {
+	merged := make([]HeatmapSlot, 0, (len(h.slots)+1)/2)
+	for i := 0; i < len(h.slots); i += 2 {
+		slot := h.slots[i]
+		if i+1 < len(h.slots) {
+			slot.Histogram.merge(h.slots[i+1].Histogram)
+			slot.Errors += h.slots[i+1].Errors
+		}
+		merged = append(merged, slot)
+	}
+	h.slots = merged
+	h.slotSize *= 2
+}
+
+// WindowHistogram represents latency histogram of successful records in window
+func (s *Detail) WindowHistogram() Histogram {
+	h := newHistogram()
+	for _, r := range s.primaryRecords() {
+		if r.Record.Successful {
+			h[BucketOf(r.Record.Cost)]++
+		}
+	}
+	return h
+}
+
+// Heatmap represents latency histograms over the session, in slots of size `slotSize`
+func (s *Detail) Heatmap() (slots []HeatmapSlot, slotSize time.Duration) {
+	return s.heatmap.slots, s.heatmap.slotSize
+}
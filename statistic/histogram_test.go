@@ -0,0 +1,42 @@
+package statistic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestBucketOf(t *testing.T) {
+	cases := map[time.Duration]int{
+		50 * time.Microsecond: 0,
+		time.Millisecond:      3,
+		40 * time.Millisecond: 6,
+		10 * time.Second:      len(LatencyBuckets),
+	}
+	for cost, expected := range cases {
+		if b := BucketOf(cost); b != expected {
+			t.Errorf("expect bucket %d of %v, but got %d", expected, cost, b)
+		}
+	}
+}
+
+func TestHeatmapMergeSlots(t *testing.T) {
+	var h heatmap
+	start := time.Now()
+	n := maxHeatmapSlots + 10
+	for i := 0; i < n; i++ {
+		h.add(start.Add(time.Duration(i)*initialHeatmapSlot), types.Record{Successful: i%2 == 0, Cost: time.Millisecond})
+	}
+	if h.slotSize != 2*initialHeatmapSlot || len(h.slots) != (n+1)/2 {
+		t.Fatalf("expect slots merged once, but got %d slots of %v", len(h.slots), h.slotSize)
+	}
+	records, errors := 0, 0
+	for _, slot := range h.slots {
+		records += slot.Histogram.Total()
+		errors += slot.Errors
+	}
+	if records+errors != n || errors != n/2 {
+		t.Errorf("expect %d records with %d errors, but got %d with %d errors", n, n/2, records+errors, errors)
+	}
+}
@@ -0,0 +1,27 @@
+package statistic
+
+// maxMarks represents how many marks are kept for each target
+const maxMarks = 64
+
+// Mark the position of the latest record, for markers dropped by user,
+// the tick is drawn on the latest record before the marker
+func (s *Detail) Mark() {
+	s.marks = append(s.marks, s.sessionCount)
+	if over := len(s.marks) - maxMarks; over > 0 {
+		s.marks = s.marks[over:]
+	}
+}
+
+// MarkIndexes represents indexes of marks in `Cost`, in ascending order
+func (s *Detail) MarkIndexes() []int {
+	var indexes []int
+	n := len(s.Cost)
+	for _, m := range s.marks {
+		back := s.sessionCount - m
+		if back < 0 || back >= n {
+			continue
+		}
+		indexes = append(indexes, n-1-back)
+	}
+	return indexes
+}
@@ -0,0 +1,32 @@
+package statistic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestMarkIndexes(t *testing.T) {
+	st := &Detail{Cost: make([]int, 5)}
+	now := time.Now()
+	deal := func(n int) {
+		for i := 0; i < n; i++ {
+			st.DealRecord(now, types.Record{Successful: true, Cost: time.Millisecond})
+		}
+	}
+	deal(2)
+	st.Mark()
+	deal(3)
+	st.Mark()
+	deal(1)
+	if indexes := st.MarkIndexes(); !reflect.DeepEqual(indexes, []int{0, 3}) {
+		t.Errorf("expect marks at [0 3], but got %v", indexes)
+	}
+	// the first mark is out of view
+	deal(2)
+	if indexes := st.MarkIndexes(); !reflect.DeepEqual(indexes, []int{1}) {
+		t.Errorf("expect marks at [1], but got %v", indexes)
+	}
+}
@@ -0,0 +1,29 @@
+package statistic
+
+import "time"
+
+// Pause the statistic at `t`, records are not retired till resumed
+func (s *Detail) Pause(t time.Time) {
+	if s.Paused {
+		return
+	}
+	s.Paused = true
+	s.pausedAt = t
+}
+
+// Resume the statistic paused at `t`, records kept are shifted by the paused duration
+// as if no time passed, but not beyond `t`
+func (s *Detail) Resume(t time.Time) {
+	if !s.Paused {
+		return
+	}
+	s.Paused = false
+	d := t.Sub(s.pausedAt)
+	for i := range s.records {
+		shifted := s.records[i].T.Add(d)
+		if shifted.After(t) {
+			shifted = t
+		}
+		s.records[i].T = shifted
+	}
+}
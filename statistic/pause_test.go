@@ -0,0 +1,27 @@
+package statistic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yittg/ving/types"
+)
+
+func TestPauseResume(t *testing.T) {
+	st := &Detail{Cost: make([]int, 1)}
+	now := time.Now()
+	st.DealRecord(now, types.Record{Successful: true, Cost: time.Millisecond})
+	st.Pause(now)
+	// a step while paused
+	st.DealRecord(now.Add(time.Hour), types.Record{Successful: false})
+	resumed := now.Add(2 * time.Hour)
+	st.Resume(resumed)
+	st.RetireRecord(resumed)
+	if count := st.primary.stat(st.records).Count; count != 2 {
+		t.Errorf("expect records kept after resumed, but got %d", count)
+	}
+	st.RetireRecord(resumed.Add(errStatisticWindow + time.Second))
+	if count := st.primary.stat(st.records).Count; count != 0 {
+		t.Errorf("expect records retired after window, but got %d", count)
+	}
+}
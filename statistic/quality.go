@@ -0,0 +1,74 @@
+package statistic
+
+import (
+	"math"
+	"time"
+)
+
+// parameters of ITU-T G.107 E-model, with default values and G.711 codec with packet loss concealment
+const (
+	// basicSignalToNoise is Ro - Is with default values of G.107
+	basicSignalToNoise = 93.2
+	// codecImpairment Ie of G.711
+	codecImpairment = 0
+	// packetLossRobustness Bpl of G.711 with packet loss concealment
+	packetLossRobustness = 25.1
+	// codecDelay for packetization and buffering of G.711 with 20ms frames
+	codecDelay = 20 * time.Millisecond
+)
+
+// RFactor estimates the transmission rating factor R of ITU-T G.107 E-model,
+// from latency, jitter and loss in window, ranged in [0, 100]
+func (s *Detail) RFactor() float64 {
+	cost := s.LastAverageCost()
+	if cost == math.MaxInt64 {
+		return 0
+	}
+	// one-way mouth-to-ear delay, with a jitter buffer of twice the jitter
+	delay := time.Duration(cost)/2 + 2*time.Duration(s.LastJitter()) + codecDelay
+	return rFactor(float64(delay)/float64(time.Millisecond), s.LastErrRate()*100)
+}
+
+// rFactor of one-way delay `d` in milliseconds and packet loss percentage `ppl`
+func rFactor(d, ppl float64) float64 {
+	// delay impairment Id
+	id := 0.024 * d
+	if d > 177.3 {
+		id += 0.11 * (d - 177.3)
+	}
+	// effective equipment impairment Ie-eff, with random loss, i.e. BurstR = 1
+	ieEff := codecImpairment + (95-codecImpairment)*ppl/(ppl+packetLossRobustness)
+	r := basicSignalToNoise - id - ieEff
+	return math.Max(0, math.Min(100, r))
+}
+
+// mos converts R factor into mean opinion score, in [1, 4.5]
+func mos(r float64) float64 {
+	switch {
+	case r <= 0:
+		return 1
+	case r >= 100:
+		return 4.5
+	default:
+		return 1 + 0.035*r + r*(r-60)*(100-r)*7e-6
+	}
+}
+
+// MOS estimates the mean opinion score of voice quality in window, in [1, 4.5]
+func (s *Detail) MOS() float64 {
+	return mos(s.RFactor())
+}
+
+// MOSLevel represents the level of voice quality, 0 for satisfied (MOS >= 4.0),
+// 1 for some users dissatisfied (MOS >= 3.6), 2 for many dissatisfied
+func (s *Detail) MOSLevel() int {
+	m := s.MOS()
+	switch {
+	case m >= 4.0:
+		return 0
+	case m >= 3.6:
+		return 1
+	default:
+		return 2
+	}
+}
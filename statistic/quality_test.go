@@ -0,0 +1,28 @@
+package statistic
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRFactorAndMOS(t *testing.T) {
+	cases := []struct {
+		delay, loss float64
+		r, mos      float64
+	}{
+		{0, 0, 93.2, 4.41},
+		{100, 0, 90.8, 4.36},
+		{300, 0, 72.5, 3.71},
+		{20, 1, 89.1, 4.32},
+		{20, 50, 29.5, 1.59},
+	}
+	for _, c := range cases {
+		r := rFactor(c.delay, c.loss)
+		if math.Abs(r-c.r) > 0.1 {
+			t.Errorf("expect R %.1f of delay %vms and loss %v%%, but got %.1f", c.r, c.delay, c.loss, r)
+		}
+		if m := mos(r); math.Abs(m-c.mos) > 0.01 {
+			t.Errorf("expect MOS %.2f of R %.1f, but got %.2f", c.mos, r, m)
+		}
+	}
+}
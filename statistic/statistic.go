@@ -2,47 +2,204 @@ package statistic
 
 import (
 	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/yittg/ving/config"
+	stconfig "github.com/yittg/ving/statistic/config"
 	"github.com/yittg/ving/types"
 )
 
 var (
 	statisticConfig    = config.GetConfig().Statistic
 	errStatisticWindow = statisticConfig.Window.Value
+	extraWindows       = extraWindowSizes(statisticConfig)
 )
 
+// extraWindowSizes are sizes of windows tracked besides the primary one, in ascending order
+func extraWindowSizes(c stconfig.Config) []time.Duration {
+	var sizes []time.Duration
+	for _, w := range c.Windows {
+		if w.Value != c.Window.Value && !containsDuration(sizes, w.Value) {
+			sizes = append(sizes, w.Value)
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	return sizes
+}
+
+func containsDuration(sizes []time.Duration, d time.Duration) bool {
+	for _, size := range sizes {
+		if size == d {
+			return true
+		}
+	}
+	return false
+}
+
+// window accumulates records in a sliding duration
+type window struct {
+	size     time.Duration
+	start    int // index of the first record in window
+	errCount int
+	cost     int64
+}
+
+func (w *window) add(record types.Record) {
+	if record.Successful {
+		w.cost += int64(record.Cost)
+	} else {
+		w.errCount++
+	}
+}
+
+func (w *window) retire(t time.Time, records []RecordAt) {
+	for ; w.start < len(records); w.start++ {
+		record := records[w.start]
+		if !record.T.Add(w.size).Before(t) {
+			break
+		}
+		if record.Record.Successful {
+			w.cost -= int64(record.Record.Cost)
+		} else {
+			w.errCount--
+		}
+	}
+}
+
+func (w *window) stat(records []RecordAt) WindowStat {
+	return WindowStat{
+		Size:     w.size,
+		Count:    len(records) - w.start,
+		ErrCount: w.errCount,
+		cost:     w.cost,
+	}
+}
+
+// WindowStat represents statistic of records in a window
+type WindowStat struct {
+	// Size of window, 0 represents the whole session
+	Size     time.Duration
+	Count    int
+	ErrCount int
+	cost     int64
+}
+
+// Name of window, like 10s, 1m, 15m or session
+func (w WindowStat) Name() string {
+	if w.Size == 0 {
+		return "session"
+	}
+	name := w.Size.String()
+	if strings.HasSuffix(name, "m0s") {
+		name = name[:len(name)-2]
+	}
+	if strings.HasSuffix(name, "h0m") {
+		name = name[:len(name)-2]
+	}
+	return name
+}
+
+// ErrRate in window
+func (w WindowStat) ErrRate() float64 {
+	if w.Count == 0 {
+		return 0
+	}
+	return float64(w.ErrCount) / float64(w.Count)
+}
+
+// AverageCost of successful records in window, math.MaxInt64 if none
+func (w WindowStat) AverageCost() int64 {
+	successfulCount := w.Count - w.ErrCount
+	if successfulCount <= 0 {
+		return math.MaxInt64
+	}
+	return w.cost / int64(successfulCount)
+}
+
 // Detail provide ability for statistic
 type Detail struct {
 	ID    int
 	Title string
+	// Group to lay out in main board, empty if none
+	Group string
 
-	Total             int
-	ErrCount          int
-	Cost              []int
-	Dead              bool
-	lastErrRecord     *ErrorRecordAt
-	lastNIterRecord   []RecordAt
-	lastNIterErrCount int
-	lastNIterCost     int64
+	Total         int
+	ErrCount      int
+	Seq           int
+	Duplicates    int
+	Reordered     int
+	Late          int
+	Cost          []int
+	Dead          bool
+	Paused        bool
+	lastErrRecord *ErrorRecordAt
+	// pausedAt is when paused, records are not retired while paused
+	pausedAt time.Time
+
+	// Family of the address, 4 or 6, 0 if not an IP target
+	Family int
+	// Peer of the other address family linked in dual-stack mode, nil if none
+	Peer *Detail
+	// AddressChanged is when the address of the target changed last time, zero if never
+	AddressChanged time.Time
+
+	// records in the longest window
+	records []RecordAt
+	// primary window decides the error rate level, latency flag and sort
+	primary *window
+	// windows in ascending order of size, including the primary one
+	windows []*window
+
+	sessionCount int
+	sessionCost  int64
+	// marks represents session count when markers dropped
+	marks []int
+
+	heatmap      heatmap
+	baseline     baseline
+	sampleScore  float64
+	windowMedian float64
+	windowScore  float64
+}
+
+func (s *Detail) initWindows() {
+	if s.primary != nil {
+		return
+	}
+	s.primary = &window{size: errStatisticWindow}
+	s.windows = []*window{s.primary}
+	for _, size := range extraWindows {
+		s.windows = append(s.windows, &window{size: size})
+	}
+	sort.SliceStable(s.windows, func(i, j int) bool { return s.windows[i].size < s.windows[j].size })
 }
 
 // DealRecord deal new record at t
 func (s *Detail) DealRecord(t time.Time, record types.Record) {
-	s.lastNIterRecord = append(s.lastNIterRecord, RecordAt{
+	s.initWindows()
+	s.records = append(s.records, RecordAt{
 		T:      t,
 		Record: record,
 	})
+	for _, w := range s.windows {
+		w.add(record)
+	}
 	s.Total = record.Rounds
+	s.Seq = record.Seq
+	s.Duplicates = record.Duplicates
+	s.Reordered = record.Reordered
+	s.Late = record.Late
+	s.sessionCount++
+	s.heatmap.add(t, record)
 
 	if record.Successful {
-		s.lastNIterCost += int64(record.Cost)
+		s.sessionCost += int64(record.Cost)
 		s.Cost = append(s.Cost[1:], int(record.Cost))
+		s.updateAnomaly(int64(record.Cost))
 	} else {
 		s.ErrCount++
-		s.lastNIterErrCount++
 		s.lastErrRecord = &ErrorRecordAt{
 			T:   t,
 			Err: record.ErrMsg,
@@ -56,28 +213,58 @@ func (s *Detail) DealRecord(t time.Time, record types.Record) {
 
 // RetireRecord retires those records out of window
 func (s *Detail) RetireRecord(t time.Time) {
-	for i := 0; i < len(s.lastNIterRecord); i++ {
-		record := s.lastNIterRecord[i]
-		if record.T.Add(errStatisticWindow).Before(t) {
-			if !record.Record.Successful {
-				s.lastNIterErrCount--
-			} else {
-				s.lastNIterCost -= int64(record.Record.Cost)
-			}
-			continue
-		}
-		s.lastNIterRecord = s.lastNIterRecord[i:]
-		break
+	s.initWindows()
+	for _, w := range s.windows {
+		w.retire(t, s.records)
+	}
+	// the longest window holds the most records
+	retired := s.windows[len(s.windows)-1].start
+	if retired == 0 {
+		return
+	}
+	s.records = s.records[retired:]
+	for _, w := range s.windows {
+		w.start -= retired
+	}
+}
+
+// TrueLoss represents errors excluding replies arrived late
+func (s *Detail) TrueLoss() int {
+	if s.Late > s.ErrCount {
+		return 0
+	}
+	return s.ErrCount - s.Late
+}
+
+// primaryRecords represents records in primary window
+func (s *Detail) primaryRecords() []RecordAt {
+	if s.primary == nil {
+		return nil
+	}
+	return s.records[s.primary.start:]
+}
+
+// Windows represents statistic of all windows in ascending order of size, and the whole session at last
+func (s *Detail) Windows() []WindowStat {
+	s.initWindows()
+	stats := make([]WindowStat, 0, len(s.windows)+1)
+	for _, w := range s.windows {
+		stats = append(stats, w.stat(s.records))
 	}
+	return append(stats, WindowStat{
+		Count:    s.sessionCount,
+		ErrCount: s.ErrCount,
+		cost:     s.sessionCost,
+	})
 }
 
 // LastRecord represents latest record
 func (s *Detail) LastRecord() *RecordAt {
-	n := len(s.lastNIterRecord)
+	n := len(s.records)
 	if n == 0 {
 		return nil
 	}
-	return &s.lastNIterRecord[n-1]
+	return &s.records[n-1]
 }
 
 // LastErrorRecord represents latest error record
@@ -87,7 +274,8 @@ func (s *Detail) LastErrorRecord() *ErrorRecordAt {
 
 // LastErrRate represents latest error rate in window
 func (s *Detail) LastErrRate() float64 {
-	return float64(s.lastNIterErrCount) / float64(len(s.lastNIterRecord))
+	s.initWindows()
+	return s.primary.stat(s.records).ErrRate()
 }
 
 // LastErrRateLevel represents the level of last error rate
@@ -103,11 +291,51 @@ func (s *Detail) LastErrRateLevel() int {
 
 // LastAverageCost represents latest speed in window
 func (s *Detail) LastAverageCost() int64 {
-	successfulCount := len(s.lastNIterRecord) - s.lastNIterErrCount
-	if successfulCount <= 0 {
+	s.initWindows()
+	return s.primary.stat(s.records).AverageCost()
+}
+
+// lastSuccessfulCosts returns costs of successful records in window, in order of time
+func (s *Detail) lastSuccessfulCosts() []int64 {
+	records := s.primaryRecords()
+	costs := make([]int64, 0, len(records))
+	for _, r := range records {
+		if r.Record.Successful {
+			costs = append(costs, int64(r.Record.Cost))
+		}
+	}
+	return costs
+}
+
+// LastPercentileCost represents the p-th percentile of latency in window
+func (s *Detail) LastPercentileCost(p float64) int64 {
+	costs := s.lastSuccessfulCosts()
+	if len(costs) == 0 {
 		return math.MaxInt64
 	}
-	return s.lastNIterCost / int64(successfulCount)
+	sort.Slice(costs, func(i, j int) bool { return costs[i] < costs[j] })
+	idx := int(math.Ceil(p/100*float64(len(costs)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return costs[idx]
+}
+
+// LastJitter represents the mean deviation of latency between consecutive successful records in window
+func (s *Detail) LastJitter() int64 {
+	costs := s.lastSuccessfulCosts()
+	if len(costs) < 2 {
+		return 0
+	}
+	var sum int64
+	for i := 1; i < len(costs); i++ {
+		d := costs[i] - costs[i-1]
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return sum / int64(len(costs)-1)
 }
 
 // LastStatisticLatencyLow represents last average cose is lower than threshold
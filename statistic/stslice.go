@@ -1,6 +1,12 @@
 package statistic
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
 
 // SortStrategy represents how to sort statistics
 type SortStrategy int
@@ -8,12 +14,60 @@ type SortStrategy int
 // Provided strategys
 const (
 	Default SortStrategy = iota
+	ByInput
+	ByName
+	ByErrRate
+	ByAverageLatency
+	ByPercentileLatency
+	ByJitter
+	ByLastError
+	ByMOS
+	sortStrategyEnd
 )
 
+// SortPercentile is the percentile used by `ByPercentileLatency`
+const SortPercentile = 95
+
+var sortStrategyNames = []string{
+	Default:             "default",
+	ByInput:             "input",
+	ByName:              "name",
+	ByErrRate:           "error-rate",
+	ByAverageLatency:    "latency",
+	ByPercentileLatency: fmt.Sprintf("p%d", SortPercentile),
+	ByJitter:            "jitter",
+	ByLastError:         "last-error",
+	ByMOS:               "mos",
+}
+
+func (s SortStrategy) String() string {
+	return sortStrategyNames[s]
+}
+
+// SortStrategyNames returns names of all strategies
+func SortStrategyNames() []string {
+	return sortStrategyNames
+}
+
+// ParseSortStrategy parses strategy by name
+func ParseSortStrategy(name string) (SortStrategy, error) {
+	for s, n := range sortStrategyNames {
+		if n == strings.ToLower(name) {
+			return SortStrategy(s), nil
+		}
+	}
+	return Default, fmt.Errorf("unknown sort strategy %s, should be one of %v", name, sortStrategyNames)
+}
+
 // StSlice helps sort statistics
 type StSlice struct {
 	Details      []*Detail
 	SortStrategy SortStrategy
+	Descending   bool
+	Pinned       map[int]bool
+
+	// cache of costly values, e.g. percentile latency, nil means no cache
+	cache map[int]int64
 }
 
 // Len of statistics
@@ -21,20 +75,191 @@ func (st StSlice) Len() int {
 	return len(st.Details)
 }
 
+func compareFloat(a, b float64) int {
+	if math.Abs(a-b) < 0.00001 {
+		return 0
+	}
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func compareInt64(a, b int64) int {
+	if a == b {
+		return 0
+	}
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func (st StSlice) value(d *Detail, f func(*Detail) int64) int64 {
+	if st.cache == nil {
+		return f(d)
+	}
+	v, ok := st.cache[d.ID]
+	if !ok {
+		v = f(d)
+		st.cache[d.ID] = v
+	}
+	return v
+}
+
+func (st StSlice) compareDefault(di, dj *Detail) int {
+	if c := compareFloat(di.LastErrRate(), dj.LastErrRate()); c != 0 {
+		return c
+	}
+	return compareInt64(di.LastAverageCost(), dj.LastAverageCost())
+}
+
+// compare two statistics by strategy, in ascending order
+func (st StSlice) compare(di, dj *Detail) int {
+	switch st.SortStrategy {
+	case ByInput:
+		return compareInt64(int64(di.ID), int64(dj.ID))
+	case ByName:
+		return strings.Compare(di.Title, dj.Title)
+	case ByErrRate:
+		return compareFloat(di.LastErrRate(), dj.LastErrRate())
+	case ByAverageLatency:
+		return compareInt64(di.LastAverageCost(), dj.LastAverageCost())
+	case ByPercentileLatency:
+		percentile := func(d *Detail) int64 { return d.LastPercentileCost(SortPercentile) }
+		return compareInt64(st.value(di, percentile), st.value(dj, percentile))
+	case ByJitter:
+		jitter := func(d *Detail) int64 { return d.LastJitter() }
+		return compareInt64(st.value(di, jitter), st.value(dj, jitter))
+	case ByLastError:
+		var ti, tj int64
+		if r := di.LastErrorRecord(); r != nil {
+			ti = r.T.UnixNano()
+		}
+		if r := dj.LastErrorRecord(); r != nil {
+			tj = r.T.UnixNano()
+		}
+		return compareInt64(ti, tj)
+	case ByMOS:
+		return compareFloat(di.MOS(), dj.MOS())
+	default:
+		return st.compareDefault(di, dj)
+	}
+}
+
 // Less compares statistics
 func (st StSlice) Less(i, j int) bool {
-	if st.Details[i].Dead {
-		return false
+	di, dj := st.Details[i], st.Details[j]
+	if di.Dead != dj.Dead {
+		return dj.Dead
+	}
+	if pi, pj := st.Pinned[di.ID], st.Pinned[dj.ID]; pi != pj {
+		return pi
+	}
+	c := st.compare(di, dj)
+	if c == 0 {
+		return di.ID < dj.ID
 	}
-	eri := st.Details[i].LastErrRate()
-	erj := st.Details[j].LastErrRate()
-	if math.Abs(eri-erj) < 0.00001 {
-		return st.Details[i].LastAverageCost() < st.Details[j].LastAverageCost()
+	if st.Descending {
+		return c > 0
 	}
-	return eri < erj
+	return c < 0
 }
 
 // Swap btw two statistics
 func (st StSlice) Swap(i, j int) {
 	st.Details[i], st.Details[j] = st.Details[j], st.Details[i]
 }
+
+// Sorter holds the sort strategy and pinned statistics, which can be changed at runtime
+type Sorter struct {
+	lock       sync.Mutex
+	strategy   SortStrategy
+	descending bool
+	pinned     map[int]bool
+	changed    bool
+}
+
+// NewSorter new a sorter with initial strategy
+func NewSorter(strategy SortStrategy, descending bool) *Sorter {
+	return &Sorter{
+		strategy:   strategy,
+		descending: descending,
+		pinned:     make(map[int]bool),
+		changed:    true,
+	}
+}
+
+// Strategy returns current strategy and whether in descending order
+func (s *Sorter) Strategy() (SortStrategy, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.strategy, s.descending
+}
+
+// Next switch to next strategy
+func (s *Sorter) Next() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.strategy = (s.strategy + 1) % sortStrategyEnd
+	s.changed = true
+}
+
+// Reverse the order
+func (s *Sorter) Reverse() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.descending = !s.descending
+	s.changed = true
+}
+
+// TogglePin pins the statistic `id` at the top, or unpins it
+func (s *Sorter) TogglePin(id int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pinned[id] {
+		delete(s.pinned, id)
+	} else {
+		s.pinned[id] = true
+	}
+	s.changed = true
+}
+
+// IsPinned checks whether statistic `id` is pinned
+func (s *Sorter) IsPinned(id int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pinned[id]
+}
+
+// PinnedCount returns count of pinned statistics
+func (s *Sorter) PinnedCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pinned)
+}
+
+// Changed represents whether strategy or pinned changed since last sort
+func (s *Sorter) Changed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changed
+}
+
+// Sort statistics in place
+func (s *Sorter) Sort(details []*Detail) {
+	s.lock.Lock()
+	st := StSlice{
+		Details:      details,
+		SortStrategy: s.strategy,
+		Descending:   s.descending,
+		Pinned:       make(map[int]bool, len(s.pinned)),
+		cache:        make(map[int]int64, len(details)),
+	}
+	for id := range s.pinned {
+		st.Pinned[id] = true
+	}
+	s.changed = false
+	s.lock.Unlock()
+	sort.Stable(st)
+}
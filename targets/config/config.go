@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	c "github.com/yittg/ving/config/encoding"
+	"github.com/yittg/ving/errors"
+)
+
+// MaxBurst is the max count of pings sent concurrently each interval
+const MaxBurst = 100
+
+// Supported types of targets
+const (
+	TypeICMP = "icmp"
+	TypeTCP  = "tcp"
+)
+
+// Target defined in configuration, zero values fall back to global ones
+type Target struct {
+	Name string
+	// Address to ping, host:port if type is tcp
+	Address string
+	// Type of probe, icmp by default, or tcp
+	Type  string
+	Group string
+	Tags  []string
+	// Interface or source address to send probes from
+	Interface string
+	// Netns is the name or path of network namespace to send probes from, or of process NetnsPid
+	Netns    string
+	NetnsPid int `toml:"netns-pid"`
+
+	Interval c.Duration
+	// Jitter of interval as a fraction of it, e.g. 0.1 for ±10%
+	Jitter float64
+	// Burst is count of pings sent concurrently each interval
+	Burst   int
+	Timeout c.Duration
+
+	// DownAfter and DegradedLatency override thresholds of event detection
+	DownAfter       int        `toml:"down-after"`
+	DegradedLatency c.Duration `toml:"degraded-latency"`
+}
+
+// Targets defined in configuration
+type Targets []Target
+
+// Validate targets, interval should be shorter than the statistic window
+func (ts Targets) Validate(statisticWindow time.Duration) error {
+	for i := range ts {
+		if err := ts[i].validate(i, statisticWindow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Target) validate(idx int, statisticWindow time.Duration) error {
+	if t.Address == "" {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("no address of target, (targets[%d])", idx),
+		}
+	}
+	if t.Type != "" && t.Type != TypeICMP && t.Type != TypeTCP {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("unknown type of target %s, should be icmp or tcp, (type=%s)", t.Address, t.Type),
+		}
+	}
+	if v := t.Interval.Value; v != 0 && (v < 10*time.Millisecond || MaxInterval(v, t.Jitter) >= statisticWindow) {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("invalid interval of target %s, should be >=10ms and shorter than statistic window %v "+
+				"with jitter, (interval=%v, jitter=%v)", t.Address, statisticWindow, v, t.Jitter),
+		}
+	}
+	if t.Jitter < 0 || t.Jitter >= 1 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("jitter of target %s should be in [0, 1), (jitter=%v)", t.Address, t.Jitter),
+		}
+	}
+	if t.Burst < 0 || t.Burst > MaxBurst {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("burst of target %s should be in [0, %d], (burst=%d)", t.Address, MaxBurst, t.Burst),
+		}
+	}
+	if v := t.Timeout.Value; v != 0 && v < 10*time.Millisecond {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("invalid timeout of target %s, should be >=10ms, (timeout=%v)", t.Address, v),
+		}
+	}
+	if t.Netns != "" && t.NetnsPid != 0 || t.NetnsPid < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("netns of target %s should be given by either name or positive pid, (netns=%s, netns-pid=%d)",
+				t.Address, t.Netns, t.NetnsPid),
+		}
+	}
+	if t.DownAfter < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("consecutive losses to be down of target %s should not be negative, (down-after=%d)",
+				t.Address, t.DownAfter),
+		}
+	}
+	if t.DegradedLatency.Value < 0 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("degraded latency of target %s should not be negative, (degraded-latency=%v)",
+				t.Address, t.DegradedLatency.Value),
+		}
+	}
+	return nil
+}
+
+// MaxInterval between rounds with jitter, which should be shorter than the statistic window
+func MaxInterval(interval time.Duration, jitter float64) time.Duration {
+	return interval + time.Duration(float64(interval)*jitter)
+}
+
+// InGroup checks whether the target is in the group, namely the group or one of tags is `group`
+func (t *Target) InGroup(group string) bool {
+	if t.Group == group {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if tag == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	c "github.com/yittg/ving/config/encoding"
+	"github.com/yittg/ving/errors"
+)
+
+// Resolve config of hostname targets
+type Resolve struct {
+	// Interval to re-resolve hostnames, 0 disables re-resolving
+	Interval c.Duration `toml:"interval"`
+	// AllAddresses pings every address of a hostname as sub-targets instead of the first one
+	AllAddresses bool `toml:"all-addresses"`
+	// DualStack pings both IPv4 and IPv6 addresses of a hostname side by side as a linked pair
+	DualStack bool `toml:"dual-stack"`
+	// Family of addresses preferred, 4 or 6, or 0 for any
+	Family int `toml:"family"`
+}
+
+// Validate Resolve
+func (r *Resolve) Validate() error {
+	if v := r.Interval.Value; v != 0 && v < time.Second {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("interval to re-resolve hostnames should be 0 or >=1s, (interval=%v)", v),
+		}
+	}
+	if r.Family != 0 && r.Family != 4 && r.Family != 6 {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("address family should be 4 or 6, or 0 for any, (family=%d)", r.Family),
+		}
+	}
+	if r.DualStack && (r.AllAddresses || r.Family != 0) {
+		return &errors.ConfigError{
+			Msg: fmt.Sprintf("dual-stack is exclusive with all-addresses and family, (all-addresses=%v, family=%d)",
+				r.AllAddresses, r.Family),
+		}
+	}
+	return nil
+}
+
+// DefaultResolve config of hostname targets, not re-resolving
+func DefaultResolve() Resolve {
+	return Resolve{}
+}
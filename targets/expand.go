@@ -0,0 +1,125 @@
+package targets
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/yittg/ving/targets/config"
+)
+
+// MaxExpansion is the upper bound of targets a CIDR or range expands into
+const MaxExpansion = 256
+
+// Expand CIDRs like 10.0.1.0/28 and ranges like 10.0.1.10-20 or 10.0.1.10-10.0.1.20 into individual targets,
+// at most `MaxExpansion` of each, returns warnings about truncated ones
+func Expand(specs []Spec) ([]Spec, []string) {
+	var expanded []Spec
+	var warnings []string
+	for _, spec := range specs {
+		if spec.Type == config.TypeTCP {
+			expanded = append(expanded, spec)
+			continue
+		}
+		ips, total, ok := expandAddress(spec.Address)
+		if !ok {
+			expanded = append(expanded, spec)
+			continue
+		}
+		if total.Cmp(big.NewInt(MaxExpansion)) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s expands to %s addresses, only the first %d are taken",
+				spec.Address, total, MaxExpansion))
+		}
+		for i, ip := range ips {
+			s := spec
+			s.Address = ip.String()
+			if spec.Name != "" {
+				s.Name = fmt.Sprintf("%s-%d", spec.Name, i+1)
+			}
+			expanded = append(expanded, s)
+		}
+	}
+	return expanded, warnings
+}
+
+// expandAddress expands a CIDR or range, returns at most `MaxExpansion` addresses and the total,
+// not ok if address is neither
+func expandAddress(address string) (ips []net.IP, total *big.Int, ok bool) {
+	if _, ipNet, err := net.ParseCIDR(address); err == nil {
+		first, last := cidrBounds(ipNet)
+		return expandBetween(first, last), count(first, last), true
+	}
+	i := strings.LastIndexByte(address, '-')
+	if i < 0 {
+		return nil, nil, false
+	}
+	first := net.ParseIP(address[:i])
+	if first == nil {
+		return nil, nil, false
+	}
+	last := net.ParseIP(address[i+1:])
+	if last == nil {
+		// only the last part is given, e.g. 10.0.1.10-20
+		if first.To4() == nil {
+			return nil, nil, false
+		}
+		end, err := strconv.Atoi(address[i+1:])
+		if err != nil || end < 0 || end > 255 {
+			return nil, nil, false
+		}
+		last = make(net.IP, net.IPv4len)
+		copy(last, first.To4())
+		last[3] = byte(end)
+	}
+	if (first.To4() == nil) != (last.To4() == nil) || toInt(first).Cmp(toInt(last)) > 0 {
+		return nil, nil, false
+	}
+	return expandBetween(first, last), count(first, last), true
+}
+
+// cidrBounds excludes the network and broadcast address of IPv4 networks larger than /31
+func cidrBounds(ipNet *net.IPNet) (first, last net.IP) {
+	ones, bits := ipNet.Mask.Size()
+	first = ipNet.IP
+	lastInt := new(big.Int).Or(toInt(first), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)), big.NewInt(1)))
+	last = fromInt(lastInt, len(first))
+	if bits == 32 && ones < 31 {
+		first = fromInt(new(big.Int).Add(toInt(first), big.NewInt(1)), len(first))
+		last = fromInt(new(big.Int).Sub(lastInt, big.NewInt(1)), len(first))
+	}
+	return
+}
+
+func toInt(ip net.IP) *big.Int {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return new(big.Int).SetBytes(ip)
+}
+
+func fromInt(v *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	b := v.Bytes()
+	copy(ip[size-len(b):], b)
+	return ip
+}
+
+func count(first, last net.IP) *big.Int {
+	return new(big.Int).Add(new(big.Int).Sub(toInt(last), toInt(first)), big.NewInt(1))
+}
+
+func expandBetween(first, last net.IP) []net.IP {
+	size := net.IPv6len
+	if first.To4() != nil {
+		size = net.IPv4len
+	}
+	var ips []net.IP
+	one := big.NewInt(1)
+	end := toInt(last)
+	for v := toInt(first); v.Cmp(end) <= 0 && len(ips) < MaxExpansion; v = new(big.Int).Add(v, one) {
+		ips = append(ips, fromInt(v, size))
+	}
+	return ips
+}
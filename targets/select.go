@@ -0,0 +1,75 @@
+package targets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yittg/ving/net/netns"
+	"github.com/yittg/ving/targets/config"
+)
+
+// GroupPrefix of arguments selecting a group of targets defined in configuration, e.g. @prod
+const GroupPrefix = "@"
+
+// FromConfig builds the spec of a target defined in configuration
+func FromConfig(t *config.Target) Spec {
+	ns := netns.Path(t.Netns)
+	if t.NetnsPid > 0 {
+		ns = netns.PathOfPid(t.NetnsPid)
+	}
+	return Spec{
+		Address:         t.Address,
+		Name:            t.Name,
+		Tags:            t.Tags,
+		Type:            t.Type,
+		Group:           t.Group,
+		Interface:       t.Interface,
+		Netns:           ns,
+		Interval:        t.Interval.Value,
+		Jitter:          t.Jitter,
+		Burst:           t.Burst,
+		Timeout:         t.Timeout.Value,
+		DownAfter:       t.DownAfter,
+		DegradedLatency: t.DegradedLatency.Value,
+	}
+}
+
+// Select specs of targets by arguments and groups, arguments like `@prod` select the group,
+// names of targets defined are replaced with their definitions, all defined are selected if nothing given
+func Select(args, groups []string, defined config.Targets) ([]Spec, error) {
+	if len(args) == 0 && len(groups) == 0 {
+		specs := make([]Spec, 0, len(defined))
+		for i := range defined {
+			specs = append(specs, FromConfig(&defined[i]))
+		}
+		return specs, nil
+	}
+	var specs []Spec
+	for _, arg := range args {
+		if strings.HasPrefix(arg, GroupPrefix) {
+			groups = append(groups, strings.TrimPrefix(arg, GroupPrefix))
+			continue
+		}
+		spec := Spec{Address: arg}
+		for i := range defined {
+			if defined[i].Name == arg {
+				spec = FromConfig(&defined[i])
+				break
+			}
+		}
+		specs = append(specs, spec)
+	}
+	for _, group := range groups {
+		found := false
+		for i := range defined {
+			if defined[i].InGroup(group) {
+				specs = append(specs, FromConfig(&defined[i]))
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no target in group %s defined", group)
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,123 @@
+package targets
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yittg/ving/net/netns"
+	"github.com/yittg/ving/targets/config"
+)
+
+// Spec of a target given by user
+type Spec struct {
+	// Address to ping, e.g. an IP or a hostname
+	Address string
+	// Name to display instead of the address, optional
+	Name string
+	// Tags of the target, optional
+	Tags []string
+	// Type of probe, icmp by default, or tcp with address like host:port
+	Type string
+	// Group to lay out the target in, optional
+	Group string
+	// Interface or source address to send probes from, optional
+	Interface string
+	// Netns is the path of network namespace to send probes from, optional
+	Netns string
+
+	// Interval, Jitter, Burst, Timeout, DownAfter and DegradedLatency override global ones if not zero
+	Interval        time.Duration
+	Jitter          float64
+	Burst           int
+	Timeout         time.Duration
+	DownAfter       int
+	DegradedLatency time.Duration
+}
+
+// ParseFile parses specs from the file at path, or stdin if path is "-", see `Parse`
+func ParseFile(path string) ([]Spec, error) {
+	if path == "-" {
+		return Parse(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse specs, one target per line like `10.0.1.1 name=web-1 tags=prod,dc1`, group, type, interface,
+// netns, netns-pid, interval, jitter and burst are also allowed,
+// blank lines and comments after `#` are ignored
+func Parse(r io.Reader) ([]Spec, error) {
+	var specs []Spec
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		spec := Spec{Address: fields[0]}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || kv[1] == "" {
+				return nil, fmt.Errorf("line %d: invalid field %s, should be like name=<name> or tags=<tag,...>", n, field)
+			}
+			switch kv[0] {
+			case "name":
+				spec.Name = kv[1]
+			case "tags":
+				spec.Tags = append(spec.Tags, strings.Split(kv[1], ",")...)
+			case "group":
+				spec.Group = kv[1]
+			case "interface":
+				spec.Interface = kv[1]
+			case "netns":
+				spec.Netns = netns.Path(kv[1])
+			case "netns-pid":
+				pid, err := strconv.Atoi(kv[1])
+				if err != nil || pid <= 0 {
+					return nil, fmt.Errorf("line %d: invalid pid %s", n, kv[1])
+				}
+				spec.Netns = netns.PathOfPid(pid)
+			case "interval":
+				interval, err := time.ParseDuration(kv[1])
+				if err != nil || interval < 10*time.Millisecond {
+					return nil, fmt.Errorf("line %d: invalid interval %s, should be >=10ms", n, kv[1])
+				}
+				spec.Interval = interval
+			case "jitter":
+				jitter, err := strconv.ParseFloat(kv[1], 64)
+				if err != nil || jitter < 0 || jitter >= 1 {
+					return nil, fmt.Errorf("line %d: invalid jitter %s, should be in [0, 1)", n, kv[1])
+				}
+				spec.Jitter = jitter
+			case "burst":
+				burst, err := strconv.Atoi(kv[1])
+				if err != nil || burst < 1 || burst > config.MaxBurst {
+					return nil, fmt.Errorf("line %d: invalid burst %s, should be in [1, %d]", n, kv[1], config.MaxBurst)
+				}
+				spec.Burst = burst
+			case "type":
+				if kv[1] != config.TypeICMP && kv[1] != config.TypeTCP {
+					return nil, fmt.Errorf("line %d: unknown type %s, should be icmp or tcp", n, kv[1])
+				}
+				spec.Type = kv[1]
+			default:
+				return nil, fmt.Errorf("line %d: unknown field %s", n, kv[0])
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, scanner.Err()
+}
@@ -0,0 +1,106 @@
+package targets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	c "github.com/yittg/ving/config/encoding"
+	"github.com/yittg/ving/targets/config"
+)
+
+func TestParse(t *testing.T) {
+	specs, err := Parse(strings.NewReader(`
+# rack a
+10.0.1.1 name=web-1 tags=prod,dc1
+10.0.1.2   # no name
+10.0.1.3 interface=eth1
+10.0.1.4 netns=pod-a
+10.0.1.4 netns-pid=1234
+10.0.1.5 interval=200ms jitter=0.1 burst=5
+
+example.com tags=dns
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Spec{
+		{Address: "10.0.1.1", Name: "web-1", Tags: []string{"prod", "dc1"}},
+		{Address: "10.0.1.2"},
+		{Address: "10.0.1.3", Interface: "eth1"},
+		{Address: "10.0.1.4", Netns: "/var/run/netns/pod-a"},
+		{Address: "10.0.1.4", Netns: "/proc/1234/ns/net"},
+		{Address: "10.0.1.5", Interval: 200 * time.Millisecond, Jitter: 0.1, Burst: 5},
+		{Address: "example.com", Tags: []string{"dns"}},
+	}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("expect %v, but got %v", expected, specs)
+	}
+	if _, err := Parse(strings.NewReader("10.0.1.1 web-1")); err == nil {
+		t.Errorf("expect error of invalid field")
+	}
+	if _, err := Parse(strings.NewReader("10.0.1.1 jitter=1.5")); err == nil {
+		t.Errorf("expect error of invalid jitter")
+	}
+}
+
+func addresses(specs []Spec) []string {
+	var as []string
+	for _, s := range specs {
+		as = append(as, s.Address)
+	}
+	return as
+}
+
+func TestExpand(t *testing.T) {
+	for _, c := range []struct {
+		address  string
+		expected []string
+	}{
+		{"10.0.1.0/30", []string{"10.0.1.1", "10.0.1.2"}},
+		{"10.0.1.4/31", []string{"10.0.1.4", "10.0.1.5"}},
+		{"10.0.1.10-12", []string{"10.0.1.10", "10.0.1.11", "10.0.1.12"}},
+		{"10.0.1.255-10.0.2.0", []string{"10.0.1.255", "10.0.2.0"}},
+		{"fd00::1-fd00::2", []string{"fd00::1", "fd00::2"}},
+		{"10.0.1.12-10", []string{"10.0.1.12-10"}},
+		{"my-host", []string{"my-host"}},
+	} {
+		expanded, warnings := Expand([]Spec{{Address: c.address}})
+		if got := addresses(expanded); !reflect.DeepEqual(got, c.expected) || len(warnings) > 0 {
+			t.Errorf("expect %s expanded into %v, but got %v, %v", c.address, c.expected, got, warnings)
+		}
+	}
+
+	expanded, warnings := Expand([]Spec{{Address: "10.0.0.0/16", Name: "dc"}})
+	if len(expanded) != MaxExpansion || len(warnings) != 1 || expanded[0].Name != "dc-1" {
+		t.Errorf("expect expansion truncated with warning, but got %d targets, %v", len(expanded), warnings)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	defined := config.Targets{
+		{Name: "web-1", Address: "10.0.1.1", Group: "prod", Interval: c.Duration{Value: time.Second}},
+		{Name: "web-2", Address: "10.0.1.2", Tags: []string{"prod", "canary"}},
+		{Name: "dev", Address: "10.0.2.1", Group: "dev"},
+	}
+	for _, s := range []struct {
+		args, groups []string
+		expected     []string
+	}{
+		{nil, nil, []string{"10.0.1.1", "10.0.1.2", "10.0.2.1"}},
+		{[]string{"@prod"}, nil, []string{"10.0.1.1", "10.0.1.2"}},
+		{[]string{"dev", "8.8.8.8"}, []string{"canary"}, []string{"10.0.2.1", "8.8.8.8", "10.0.1.2"}},
+	} {
+		specs, err := Select(s.args, s.groups, defined)
+		if got := addresses(specs); err != nil || !reflect.DeepEqual(got, s.expected) {
+			t.Errorf("expect %v selected by %v %v, but got %v, %v", s.expected, s.args, s.groups, got, err)
+		}
+	}
+	if specs, _ := Select([]string{"web-1"}, nil, defined); specs[0].Interval != time.Second || specs[0].Name != "web-1" {
+		t.Errorf("expect definition of web-1, but got %+v", specs[0])
+	}
+	if _, err := Select([]string{"@none"}, nil, defined); err == nil {
+		t.Errorf("expect error of unknown group")
+	}
+}
@@ -11,6 +11,7 @@ import (
 type RecordHeader struct {
 	ID     int
 	Target *protocol.NetworkTarget
+	// Rounds of the target including this record, counted when dealt
 	Rounds int
 }
 
@@ -25,4 +26,10 @@ type Record struct {
 	Cost       time.Duration
 	ErrMsg     string
 	IsFatal    bool
+
+	// Seq of the echo request, and accumulated abnormal replies of the target
+	Seq        int
+	Duplicates int
+	Reordered  int
+	Late       int
 }
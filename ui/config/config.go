@@ -8,8 +8,11 @@ import (
 
 // UIConfig for custom chart board
 type UIConfig struct {
-	MaxRow          int `toml:"max-chart-row"`
-	SparklineHeight int `toml:"chart-height"`
+	MaxRow          int    `toml:"max-chart-row"`
+	SparklineHeight int    `toml:"chart-height"`
+	SortBy          string `toml:"sort-by"`
+	SortDesc        bool   `toml:"sort-desc"`
+	MOS             bool   `toml:"mos"`
 }
 
 // Validate UIConfig
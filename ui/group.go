@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gizak/termui"
+	"github.com/yittg/ving/statistic"
+)
+
+// ungrouped is the name of the group of targets without group
+const ungrouped = "ungrouped"
+
+// groupHeader represents aggregate statistic of targets in a group
+type groupHeader struct {
+	name      string
+	members   int
+	errRate   float64
+	avgCost   int64
+	worst     *statistic.Detail
+	collapsed bool
+	// verdict of linked dual-stack peers, like "v6 slower x2.3", empty if fine
+	verdict string
+}
+
+// boardItem in main board, either a group header or a target
+type boardItem struct {
+	header *groupHeader
+	st     *statistic.Detail
+}
+
+// worse compares statistics by error rate and average latency
+func worse(a, b *statistic.Detail) bool {
+	if ea, eb := a.LastErrRate(), b.LastErrRate(); ea != eb {
+		return ea > eb
+	}
+	return a.LastAverageCost() > b.LastAverageCost()
+}
+
+func newGroupHeader(name string, members []*statistic.Detail, collapsed bool) *groupHeader {
+	h := &groupHeader{name: name, members: len(members), avgCost: math.MaxInt64, collapsed: collapsed}
+	var sumCost int64
+	costs := 0
+	for _, st := range members {
+		h.errRate += st.LastErrRate()
+		if cost := st.LastAverageCost(); cost != math.MaxInt64 {
+			sumCost += cost
+			costs++
+		}
+		if h.worst == nil || worse(st, h.worst) {
+			h.worst = st
+		}
+	}
+	h.errRate /= float64(len(members))
+	if costs > 0 {
+		h.avgCost = sumCost / int64(costs)
+	}
+	h.verdict = peersVerdict(members)
+	return h
+}
+
+// peersVerdict of the first family broken or slower among linked dual-stack peers, empty if fine
+func peersVerdict(members []*statistic.Detail) string {
+	for _, st := range members {
+		if st.Peer == nil {
+			continue
+		}
+		if st.Peer.Dead {
+			return fmt.Sprintf("v%d broken", st.Peer.Family)
+		}
+		switch st.AgainstPeer() {
+		case statistic.PeerBroken:
+			return fmt.Sprintf("v%d broken", st.Family)
+		case statistic.PeerSlower:
+			return fmt.Sprintf("v%d slower x%.1f", st.Family, st.PeerLatencyRatio())
+		}
+	}
+	return ""
+}
+
+// layoutBoard arranges targets by group, each led by a header, in order of the first member,
+// returns nil if no target has group. Targets in collapsed groups are not visible.
+func (c *Console) layoutBoard(actives []*statistic.Detail) (items []boardItem, visible []*statistic.Detail) {
+	var names []string
+	members := make(map[string][]*statistic.Detail)
+	for _, st := range actives {
+		name := st.Group
+		if name == "" {
+			name = ungrouped
+		}
+		if _, ok := members[name]; !ok {
+			names = append(names, name)
+		}
+		members[name] = append(members[name], st)
+	}
+	if len(names) == 1 && names[0] == ungrouped {
+		return nil, actives
+	}
+	for _, name := range names {
+		collapsed := c.isCollapsed(name)
+		items = append(items, boardItem{header: newGroupHeader(name, members[name], collapsed)})
+		if collapsed {
+			continue
+		}
+		for _, st := range pairUp(members[name]) {
+			items = append(items, boardItem{st: st})
+			visible = append(visible, st)
+		}
+	}
+	return items, visible
+}
+
+// pairUp places linked dual-stack peers next to each other, in order of the first of each pair
+func pairUp(members []*statistic.Detail) []*statistic.Detail {
+	in := make(map[*statistic.Detail]bool, len(members))
+	for _, st := range members {
+		in[st] = true
+	}
+	placed := make(map[*statistic.Detail]bool, len(members))
+	paired := make([]*statistic.Detail, 0, len(members))
+	for _, st := range members {
+		if placed[st] {
+			continue
+		}
+		placed[st] = true
+		paired = append(paired, st)
+		if p := st.Peer; p != nil && in[p] && !placed[p] {
+			placed[p] = true
+			paired = append(paired, p)
+		}
+	}
+	return paired
+}
+
+func (c *Console) isCollapsed(group string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.collapsed[group]
+}
+
+// toggleGroup collapses the group of the selected target, or expands it, all groups if none selected
+func (c *Console) toggleGroup() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	group, ok := c.groups[c.selected]
+	if !ok {
+		c.toggleAllGroupsLocked()
+		return
+	}
+	if group == "" {
+		group = ungrouped
+	}
+	c.collapsed[group] = !c.collapsed[group]
+}
+
+// toggleAllGroups collapses all groups, or expands all if any collapsed
+func (c *Console) toggleAllGroups() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.toggleAllGroupsLocked()
+}
+
+func (c *Console) toggleAllGroupsLocked() {
+	if len(c.collapsed) > 0 {
+		c.collapsed = make(map[string]bool)
+		return
+	}
+	for _, group := range c.groups {
+		if group == "" {
+			group = ungrouped
+		}
+		c.collapsed[group] = true
+	}
+}
+
+func (c *Console) renderGroupHeader(sp *termui.Sparkline, width int, h *groupHeader) {
+	flag := "▼"
+	if h.collapsed {
+		flag = "▶"
+	}
+	summary := fmt.Sprintf("%s %s #%d", flag, h.name, h.members)
+	res := fmt.Sprintf("loss %.1f%% avg %s worst %s", h.errRate*100, formatCost(h.avgCost), h.worst.Title)
+	if h.verdict != "" {
+		summary += " ⚠ " + h.verdict
+	}
+	textLen := width - 1
+	format := fmt.Sprintf("%%-%ds%%%dv", textLen/2, textLen-textLen/2-1)
+	sp.Title = fmt.Sprintf(format, summary, res)
+	sp.TitleColor = termui.ColorWhite | termui.AttrBold | termui.AttrUnderline
+	if h.worst.LastErrRate() > 0 || h.verdict != "" {
+		sp.TitleColor = termui.ColorRed | termui.AttrBold | termui.AttrUnderline
+	}
+	sp.Height = 0
+	sp.Data = nil
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gizak/termui"
+)
+
+const maxPromptLen = 128
+
+// prompt reads a line of text in the status bar, key handlers are suppressed while it is open
+type prompt struct {
+	lock  sync.Mutex
+	label string
+	text  []rune
+	open  bool
+	// skip the event which opens the prompt
+	skip bool
+	done func(string)
+}
+
+// start the prompt, `done` is called with text entered unless canceled
+func (p *prompt) start(label string, done func(string)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.label = label
+	p.text = p.text[:0]
+	p.open = true
+	p.skip = true
+	p.done = done
+}
+
+func (p *prompt) active() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.open
+}
+
+// hook handles key events while the prompt is open, see `termui.EventHook`
+func (p *prompt) hook(event termui.Event) {
+	p.lock.Lock()
+	if !p.open || event.Type != termui.KeyboardEvent {
+		p.lock.Unlock()
+		return
+	}
+	if p.skip {
+		p.skip = false
+		p.lock.Unlock()
+		return
+	}
+	var done func(string)
+	var text string
+	switch event.ID {
+	case "<Enter>":
+		p.open = false
+		done, text = p.done, string(p.text)
+	case "<Escape>", "<C-c>":
+		p.open = false
+	case "<Backspace>", "<C-8>":
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	case "<Space>":
+		p.append(' ')
+	default:
+		if r := []rune(event.ID); len(r) == 1 {
+			p.append(r[0])
+		}
+	}
+	p.lock.Unlock()
+	if done != nil {
+		done(text)
+	}
+}
+
+func (p *prompt) append(r rune) {
+	if len(p.text) < maxPromptLen {
+		p.text = append(p.text, r)
+	}
+}
+
+// view of the prompt in status bar
+func (p *prompt) view() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return fmt.Sprintf("[%s:](fg-bold) %s█ [(enter to confirm, esc to cancel)](fg-blue)", p.label, string(p.text))
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"github.com/gizak/termui"
+)
+
+const markColor = termui.ColorYellow
+
+// markedSparklines draws vertical ticks of markers over sparklines
+type markedSparklines struct {
+	*termui.Sparklines
+
+	// marks represents indexes in data of each line
+	marks [][]int
+}
+
+func newMarkedSparklines() *markedSparklines {
+	return &markedSparklines{Sparklines: termui.NewSparklines()}
+}
+
+// Buffer implements termui.Bufferer
+func (sl *markedSparklines) Buffer() termui.Buffer {
+	buf := sl.Sparklines.Buffer()
+	inner := sl.InnerBounds()
+	oftY := 0
+	for i, l := range sl.Lines {
+		displayHeight := l.Height
+		if l.Title != "" {
+			displayHeight++
+		}
+		if oftY+displayHeight > inner.Dy() {
+			break
+		}
+		if i < len(sl.marks) {
+			skipped := 0
+			if len(l.Data) > inner.Dx() {
+				skipped = len(l.Data) - inner.Dx()
+			}
+			for _, idx := range sl.marks[i] {
+				if idx < skipped {
+					continue
+				}
+				x := inner.Min.X + idx - skipped
+				for y := inner.Min.Y + oftY + displayHeight - l.Height; y < inner.Min.Y+oftY+displayHeight; y++ {
+					buf.Set(x, y, markCell(buf.At(x, y), l.LineColor))
+				}
+			}
+		}
+		oftY += displayHeight
+	}
+	return buf
+}
+
+// markCell draws a tick in the blank cell, or highlights the bar
+func markCell(cell termui.Cell, lineColor termui.Attribute) termui.Cell {
+	if cell.Ch == ' ' && cell.Bg == lineColor {
+		cell.Bg = markColor
+		return cell
+	}
+	if cell.Ch == ' ' {
+		cell.Ch = '┊'
+	}
+	cell.Fg = markColor
+	return cell
+}
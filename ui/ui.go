@@ -3,17 +3,50 @@ package ui
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gizak/termui"
 	"github.com/yittg/ving/addons"
 	"github.com/yittg/ving/config"
+	"github.com/yittg/ving/options"
 	"github.com/yittg/ving/statistic"
 	"github.com/yittg/ving/types"
 	"github.com/yittg/ving/utils/slices"
 )
 
+// addressChangedHighlight is how long the address changed flag shown after re-resolved
+const addressChangedHighlight = time.Minute
+
+// noticeDuration is how long a notice shown in the status bar
+const noticeDuration = 5 * time.Second
+
+// rows of the console body
+const (
+	statusRow = iota
+	mainRow
+	addOnRow
+)
+
+// Controller controls the engine at runtime
+type Controller interface {
+	// Mark drops a marker with note
+	Mark(note string)
+	// AddTarget starts pinging a new target
+	AddTarget(raw string) error
+	// RemoveTarget stops pinging target `id` and drops it
+	RemoveTarget(id int)
+	// TogglePause pauses pinging target `id`, or resumes it
+	TogglePause(id int)
+	// TogglePauseAll pauses pinging all targets, or resumes them
+	TogglePauseAll()
+	// Step sends a round to paused target `id`, or all paused targets if `id` is negative
+	Step(id int)
+}
+
 // Console display
 type Console struct {
 	colorSeed int
@@ -26,20 +59,50 @@ type Console struct {
 	active       int
 	dead         int
 	collapseDead bool
+	showWindows  bool
 
 	maxRowN         int
 	sparklineHeight int
+	showMOS         bool
+
+	status *termui.Par
+	prompt prompt
+	// notice shown in the status bar till noticeUntil
+	notice      string
+	noticeUntil time.Time
+
+	sorter     *statistic.Sorter
+	controller Controller
+
+	// lock protects the selection of main board, which is changed by key events
+	lock     sync.Mutex
+	selected int
+	order    []int
+	titles   map[int]string
+	// groups of active targets, and those collapsed
+	groups    map[int]string
+	collapsed map[string]bool
 }
 
 // NewConsole init console
-func NewConsole(addOns []addons.UI) *Console {
+func NewConsole(opt *options.Option, addOns []addons.UI, sorter *statistic.Sorter, controller Controller) *Console {
 	uiConfig := config.GetConfig().UI
 	rand.Seed(time.Now().Unix())
+	status := termui.NewPar("")
+	status.Height = 1
+	status.Border = false
 	return &Console{
 		colorSeed:       rand.Intn(termui.NumberofColors - 2),
 		addOns:          addOns,
 		maxRowN:         uiConfig.MaxRow,
 		sparklineHeight: uiConfig.SparklineHeight,
+		showMOS:         opt.MOS,
+		status:          status,
+		sorter:          sorter,
+		controller:      controller,
+		selected:        -1,
+		collapsed:       make(map[string]bool),
+		showWindows:     true,
 	}
 }
 
@@ -47,8 +110,8 @@ func (c *Console) color(id int) termui.Attribute {
 	return termui.Attribute((c.colorSeed+id)%(termui.NumberofColors-2) + 2)
 }
 
-func (c *Console) emptySpGroup() *termui.Sparklines {
-	g := termui.NewSparklines()
+func (c *Console) emptySpGroup() *markedSparklines {
+	g := newMarkedSparklines()
 	g.Border = false
 	return g
 }
@@ -93,7 +156,7 @@ func (c *Console) alignMainBlock(active, dead int) {
 	if dead > 0 {
 		cols = append(cols, termui.NewCol(deadSpan, 0, c.emptyList()))
 	}
-	termui.Body.Rows[0].Cols = cols
+	termui.Body.Rows[mainRow].Cols = cols
 	termui.Clear()
 	termui.Body.Align()
 }
@@ -107,25 +170,112 @@ func (c *Console) renderOneSp(sp *termui.Sparkline, width int, s *statistic.Deta
 	errRateFlag := []string{"🐸", "🦁", "🙈"}
 	maxLevel := len(errRateFlag) - 1
 	errRateLevel := s.LastErrRateLevel()
+	if c.showMOS {
+		errRateLevel = s.MOSLevel()
+	}
 	if errRateLevel > maxLevel {
 		errRateLevel = maxLevel
 	}
 	flag := errRateFlag[errRateLevel]
+	if c.showMOS {
+		flag += fmt.Sprintf(" MOS %.1f R%.0f", s.MOS(), s.RFactor())
+	}
 	if s.LastStatisticLatencyLow() {
 		flag += " ⚡️"
 	}
 
+	lineColor := c.color(s.ID)
+	if s.Anomalous() {
+		flag += " 📈"
+		lineColor = termui.ColorRed | termui.AttrBold
+	} else if s.LastSampleAnomalous() {
+		lineColor = termui.ColorMagenta
+	}
+
+	switch s.AgainstPeer() {
+	case statistic.PeerBroken:
+		flag += " 💔"
+		lineColor = termui.ColorRed | termui.AttrBold
+	case statistic.PeerSlower:
+		flag += " 🐢"
+	}
+
+	if c.sorter.IsPinned(s.ID) {
+		flag += " 📌"
+	}
+	if s.Paused {
+		flag += " ⏸"
+	}
+	if !s.AddressChanged.IsZero() && time.Since(s.AddressChanged) < addressChangedHighlight {
+		flag += " ⇄"
+	}
+
 	title := fmt.Sprintf("%s %s", flag, s.Title)
-	res := fmt.Sprintf("%v #%d[#%d]", lastRecord.View(), s.Total, s.ErrCount)
+	res := fmt.Sprintf("%v #%d[#%d%s]", lastRecord.View(), s.Total, s.ErrCount, abnormalReplies(s))
 	textLen := width - 1
+	if c.showWindows {
+		res = appendWindows(res, textLen-textLen/2-1, s.Windows())
+	}
 	format := fmt.Sprintf("%%-%ds%%%dv", textLen/2, textLen-textLen/2-1)
 	sp.Title = fmt.Sprintf(format, title, res)
+	if c.Selected() == s.ID {
+		sp.TitleColor = termui.ColorYellow | termui.AttrBold
+	} else {
+		sp.TitleColor = termui.ColorWhite
+	}
 	sp.Data = s.Cost
-	sp.LineColor = c.color(s.ID)
+	sp.LineColor = lineColor
 	s.ResizeViewWindow(width - 1)
 }
 
-func (c *Console) adjustSpGroup(group *termui.Sparklines, unitSize int) {
+// abnormalReplies represents accumulated late, duplicate and reordered replies if any
+func abnormalReplies(s *statistic.Detail) string {
+	var items []string
+	for _, r := range []struct {
+		name  string
+		count int
+	}{{"late", s.Late}, {"dup", s.Duplicates}, {"reord", s.Reordered}} {
+		if r.count > 0 {
+			items = append(items, fmt.Sprintf(" %s#%d", r.name, r.count))
+		}
+	}
+	return strings.Join(items, "")
+}
+
+// appendWindows appends statistic of windows to `res` as many as `maxLen` allows
+func appendWindows(res string, maxLen int, windows []statistic.WindowStat) string {
+	var items []string
+	n := len(res)
+	for _, w := range windows {
+		item := fmt.Sprintf("%s:%.1f%%/%s", w.Name(), w.ErrRate()*100, formatCost(w.AverageCost()))
+		if n+len(item)+1 > maxLen {
+			break
+		}
+		n += len(item) + 1
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return res
+	}
+	return strings.Join(items, " ") + " " + res
+}
+
+func formatCost(cost int64) string {
+	if cost == math.MaxInt64 {
+		return "-"
+	}
+	v := time.Duration(cost)
+	if v > time.Second {
+		v = v.Truncate(10 * time.Millisecond)
+	} else if v > time.Millisecond {
+		v = v.Truncate(100 * time.Microsecond)
+	} else {
+		v = v.Truncate(time.Microsecond)
+	}
+	return v.String()
+}
+
+func (c *Console) adjustSpGroup(group *markedSparklines, unitSize int) {
 	crtSize := len(group.Lines)
 	if crtSize > unitSize {
 		group.Lines = group.Lines[:unitSize]
@@ -140,20 +290,28 @@ func (c *Console) adjustSpGroup(group *termui.Sparklines, unitSize int) {
 	}
 }
 
-func (c *Console) renderOneSpGroup(ord int, unit []*statistic.Detail) {
-	group := termui.Body.Rows[0].Cols[ord].Widget.(*termui.Sparklines)
+func (c *Console) renderOneSpGroup(ord int, unit []boardItem) {
+	group := termui.Body.Rows[mainRow].Cols[ord].Widget.(*markedSparklines)
 	c.adjustSpGroup(group, len(unit))
 	height := 1
+	group.marks = group.marks[:0]
 	for i := range group.Lines {
 		sp := &(group.Lines[i])
+		if h := unit[i].header; h != nil {
+			c.renderGroupHeader(sp, group.Width, h)
+			group.marks = append(group.marks, nil)
+		} else {
+			sp.Height = c.sparklineHeight
+			c.renderOneSp(sp, group.Width, unit[i].st)
+			group.marks = append(group.marks, unit[i].st.MarkIndexes())
+		}
 		height += sp.Height + 1
-		c.renderOneSp(sp, group.Width, unit[i])
 	}
 	group.Height = height
 }
 
 func (c *Console) renderDeads(ord int, deads []*statistic.Detail) {
-	list := termui.Body.Rows[0].Cols[ord].Widget.(*termui.List)
+	list := termui.Body.Rows[mainRow].Cols[ord].Widget.(*termui.List)
 
 	var items []string
 	if c.collapseDead {
@@ -171,6 +329,62 @@ func (c *Console) renderDeads(ord int, deads []*statistic.Detail) {
 	list.Height = len(items)
 }
 
+// Notify shows the text with markups in the status bar for a while, e.g. warnings
+func (c *Console) Notify(text string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.notice = text
+	c.noticeUntil = time.Now().Add(noticeDuration)
+}
+
+func (c *Console) currentNotice(t time.Time) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if t.After(c.noticeUntil) {
+		return ""
+	}
+	return c.notice
+}
+
+func (c *Console) renderStatus(t time.Time, active, dead, paused int) {
+	if c.prompt.active() {
+		c.status.Text = c.prompt.view()
+		return
+	}
+	items := []string{fmt.Sprintf("[targets #%d](fg-bold)", active+dead)}
+	if notice := c.currentNotice(t); notice != "" {
+		items = append(items, notice)
+	}
+	if dead > 0 {
+		items = append(items, fmt.Sprintf("[dead #%d](fg-red)", dead))
+	}
+	if paused > 0 && paused == active {
+		items = append(items, "[⏸ all paused](fg-yellow)")
+	} else if paused > 0 {
+		items = append(items, fmt.Sprintf("[⏸ paused #%d](fg-yellow)", paused))
+	}
+	strategy, descending := c.sorter.Strategy()
+	order := "↑"
+	if descending {
+		order = "↓"
+	}
+	items = append(items, fmt.Sprintf("sort by %s %s", strategy, order))
+	if pinned := c.sorter.PinnedCount(); pinned > 0 {
+		items = append(items, fmt.Sprintf("pinned #%d", pinned))
+	}
+	if title, ok := c.selectedTitle(); ok {
+		items = append(items, fmt.Sprintf("selected [%s](fg-yellow)", title))
+	}
+	for _, addOn := range c.addOns {
+		if indicator, ok := addOn.(addons.Indicator); ok {
+			if s := indicator.Indicator(); s != "" {
+				items = append(items, s)
+			}
+		}
+	}
+	c.status.Text = strings.Join(items, " | ")
+}
+
 // Render statistics
 func (c *Console) Render(t time.Time, sts []*statistic.Detail) {
 	total := len(sts)
@@ -178,28 +392,40 @@ func (c *Console) Render(t time.Time, sts []*statistic.Detail) {
 	activeTargetSet := make(map[int]bool)
 	var activeTargets []*statistic.Detail
 	var deads []*statistic.Detail
+	paused := 0
 	for _, st := range sts {
 		if st.Dead {
 			deads = append(deads, st)
 			continue
 		}
+		if st.Paused {
+			paused++
+		}
 		activeTargetSet[st.ID] = true
 		activeTargets = append(activeTargets, st)
 	}
 	activeTotal := len(activeTargets)
-	c.alignMainBlock(activeTotal, total-activeTotal)
+	items, visible := c.layoutBoard(activeTargets)
+	if items == nil {
+		for _, st := range activeTargets {
+			items = append(items, boardItem{st: st})
+		}
+	}
+	c.updateOrder(activeTargets, visible)
+	c.alignMainBlock(len(items), total-activeTotal)
 	ord := 0
-	for i := 0; i < activeTotal; i += c.chartRowN {
-		if i+c.chartRowN >= activeTotal {
-			c.renderOneSpGroup(ord, activeTargets[i:])
+	for i := 0; i < len(items); i += c.chartRowN {
+		if i+c.chartRowN >= len(items) {
+			c.renderOneSpGroup(ord, items[i:])
 		} else {
-			c.renderOneSpGroup(ord, activeTargets[i:i+c.chartRowN])
+			c.renderOneSpGroup(ord, items[i:i+c.chartRowN])
 		}
 		ord++
 	}
 	if len(deads) > 0 {
 		c.renderDeads(ord, deads)
 	}
+	c.renderStatus(t, activeTotal, total-activeTotal, paused)
 
 	if c.activeAddOn != nil {
 		c.activeAddOn.UpdateState(t, activeTargetSet)
@@ -208,6 +434,64 @@ func (c *Console) Render(t time.Time, sts []*statistic.Detail) {
 	termui.Render(termui.Body)
 }
 
+// updateOrder of visible targets displayed for selection, the selection is kept if it's still active
+func (c *Console) updateOrder(actives, visible []*statistic.Detail) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.order = c.order[:0]
+	for _, st := range visible {
+		c.order = append(c.order, st.ID)
+	}
+	c.titles = make(map[int]string, len(actives))
+	c.groups = make(map[int]string, len(actives))
+	for _, st := range actives {
+		c.titles[st.ID] = st.Title
+		c.groups[st.ID] = st.Group
+	}
+	if _, ok := c.titles[c.selected]; !ok {
+		c.selected = -1
+	}
+}
+
+// Selected returns the target ID selected in main board, -1 if none
+func (c *Console) Selected() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.selected
+}
+
+func (c *Console) selectedTitle() (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	title, ok := c.titles[c.selected]
+	return title, ok
+}
+
+// moveSelection moves the selection in main board by `step`, in order displayed
+func (c *Console) moveSelection(step int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	n := len(c.order)
+	if n == 0 {
+		return
+	}
+	idx := slices.IndexIntOf(c.order, c.selected)
+	if idx < 0 {
+		if step > 0 {
+			idx = -1
+		} else {
+			idx = 0
+		}
+	}
+	c.selected = c.order[((idx+step)%n+n)%n]
+}
+
+func (c *Console) clearSelection() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.selected = -1
+}
+
 func (c *Console) setAddOn(addOn addons.UI) {
 	c.activeAddOn = addOn
 	termui.Body.AddRows(addOn.Render())
@@ -222,7 +506,7 @@ func (c *Console) removeAddOn() {
 	}
 	c.activeAddOn.Deactivate()
 	c.activeAddOn = nil
-	termui.Body.Rows = termui.Body.Rows[:1]
+	termui.Body.Rows = termui.Body.Rows[:addOnRow]
 	termui.Clear()
 	termui.Body.Align()
 }
@@ -250,14 +534,14 @@ func (c *Console) registerAddOnEvents(systemKeys []string) {
 		}
 	}
 
-	termui.Handle("<Enter>", onAddOnActive(func(event termui.Event) {
+	c.handle("<Enter>", onAddOnActive(func(event termui.Event) {
 		if cAwareAddOn, ok := c.activeAddOn.(addons.ConfirmAware); ok {
 			cAwareAddOn.OnEnter()
 		}
 	}))
 
 	systemKeys = append(systemKeys, "j", "k")
-	termui.Handle("<Up>", "<Down>", "j", "k", onAddOnActive(func(event termui.Event) {
+	c.handle([]string{"<Up>", "<Down>", "j", "k"}, onAddOnActive(func(event termui.Event) {
 		if vdAwareAddOn, ok := c.activeAddOn.(addons.VerticalDirectionAware); ok {
 			switch event.ID {
 			case "<Up>", "k":
@@ -268,6 +552,17 @@ func (c *Console) registerAddOnEvents(systemKeys []string) {
 		}
 	}))
 
+	c.handle([]string{"<Left>", "<Right>"}, onAddOnActive(func(event termui.Event) {
+		if hdAwareAddOn, ok := c.activeAddOn.(addons.HorizontalDirectionAware); ok {
+			switch event.ID {
+			case "<Left>":
+				hdAwareAddOn.OnLeft()
+			case "<Right>":
+				hdAwareAddOn.OnRight()
+			}
+		}
+	}))
+
 	var keys []string
 	for _, addOn := range c.addOns {
 		for _, em := range addOn.RespondEvents() {
@@ -277,7 +572,7 @@ func (c *Console) registerAddOnEvents(systemKeys []string) {
 		if addOn.ActivateAfterStart() {
 			c.setAddOn(addOn)
 		}
-		termui.Handle(addOn.ToggleKey(), func(a addons.UI) func(termui.Event) {
+		c.handle(addOn.ToggleKey(), func(a addons.UI) func(termui.Event) {
 			return func(termui.Event) {
 				c.toggleAddOn(a)
 			}
@@ -287,12 +582,22 @@ func (c *Console) registerAddOnEvents(systemKeys []string) {
 		if len(key) == 0 || key[0] == '<' || slices.ContainStr(systemKeys, key) {
 			continue
 		}
-		termui.Handle(key, onAddOnActive(func(event termui.Event) {
+		c.handle(key, onAddOnActive(func(event termui.Event) {
 			c.activeAddOn.HandleKeyEvent(event)
 		}))
 	}
 }
 
+// handle key events like `termui.Handle`, but suppressed while the prompt is open
+func (c *Console) handle(keys interface{}, f func(termui.Event)) {
+	termui.Handle(keys, func(event termui.Event) {
+		if c.prompt.active() {
+			return
+		}
+		f(event)
+	})
+}
+
 func (c *Console) prepareGlobalKeys(cancelFunc context.CancelFunc) (systemKeys []string) {
 	quitKey := types.EventMeta{
 		Keys:        []string{"q", "<C-c>"},
@@ -300,7 +605,10 @@ func (c *Console) prepareGlobalKeys(cancelFunc context.CancelFunc) (systemKeys [
 	}
 	systemKeys = append(systemKeys, quitKey.Keys...)
 	GlobalKeys = append(GlobalKeys, quitKey)
-	termui.Handle(quitKey.Keys, func(termui.Event) {
+	termui.Handle(quitKey.Keys, func(event termui.Event) {
+		if event.ID != "<C-c>" && c.prompt.active() {
+			return
+		}
 		cancelFunc()
 		termui.StopLoop()
 	})
@@ -311,10 +619,115 @@ func (c *Console) prepareGlobalKeys(cancelFunc context.CancelFunc) (systemKeys [
 	}
 	systemKeys = append(systemKeys, collapseDeadKey.Keys...)
 	GlobalKeys = append(GlobalKeys, collapseDeadKey)
-	termui.Handle(collapseDeadKey.Keys, func(termui.Event) {
+	c.handle(collapseDeadKey.Keys, func(termui.Event) {
 		c.dead = 0 // trigger re-align main block
 		c.collapseDead = !c.collapseDead
 	})
+
+	systemKeys = append(systemKeys, c.prepareBoardKeys()...)
+	return
+}
+
+func (c *Console) prepareBoardKeys() (systemKeys []string) {
+	handlers := []struct {
+		meta types.EventMeta
+		f    func(termui.Event)
+	}{
+		{
+			meta: types.EventMeta{Keys: []string{"[", "]"}, Description: "select previous/next target in main board"},
+			f: func(event termui.Event) {
+				if event.ID == "[" {
+					c.moveSelection(-1)
+				} else {
+					c.moveSelection(1)
+				}
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"<Escape>"}, Description: "clear the selection in main board"},
+			f:    func(termui.Event) { c.clearSelection() },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"w"}, Description: "toggle statistic of windows in titles"},
+			f:    func(termui.Event) { c.showWindows = !c.showWindows },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"s"}, Description: "switch to next sort strategy"},
+			f:    func(termui.Event) { c.sorter.Next() },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"S"}, Description: "reverse the sort order"},
+			f:    func(termui.Event) { c.sorter.Reverse() },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"M"}, Description: "drop a marker with note"},
+			f: func(termui.Event) {
+				c.prompt.start("marker note", c.controller.Mark)
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"g"}, Description: "collapse/expand the group of the selected target"},
+			f:    func(termui.Event) { c.toggleGroup() },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"G"}, Description: "collapse/expand all groups"},
+			f:    func(termui.Event) { c.toggleAllGroups() },
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"a"}, Description: "add a target"},
+			f: func(termui.Event) {
+				c.prompt.start("add target", func(raw string) {
+					if raw = strings.TrimSpace(raw); raw == "" {
+						return
+					}
+					if err := c.controller.AddTarget(raw); err != nil {
+						c.Notify(fmt.Sprintf("[add %s failed, %v](fg-red)", raw, err))
+					}
+				})
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"-"}, Description: "remove the selected target"},
+			f: func(termui.Event) {
+				if selected := c.Selected(); selected >= 0 {
+					c.controller.RemoveTarget(selected)
+				}
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"z"}, Description: "pause/resume the selected target"},
+			f: func(termui.Event) {
+				if selected := c.Selected(); selected >= 0 {
+					c.controller.TogglePause(selected)
+				}
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"<Space>"}, Description: "pause/resume all targets"},
+			f: func(termui.Event) {
+				c.controller.TogglePauseAll()
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"."}, Description: "send a round to the selected paused target, or all paused ones"},
+			f: func(termui.Event) {
+				c.controller.Step(c.Selected())
+			},
+		},
+		{
+			meta: types.EventMeta{Keys: []string{"*"}, Description: "pin/unpin the selected target at the top"},
+			f: func(termui.Event) {
+				if selected := c.Selected(); selected >= 0 {
+					c.sorter.TogglePin(selected)
+				}
+			},
+		},
+	}
+	for _, h := range handlers {
+		systemKeys = append(systemKeys, h.meta.Keys...)
+		GlobalKeys = append(GlobalKeys, h.meta)
+		c.handle(h.meta.Keys, h.f)
+	}
 	return
 }
 
@@ -324,8 +737,10 @@ func (c *Console) Run(cancelFunc context.CancelFunc) {
 		panic(err)
 	}
 	defer termui.Close()
+	termui.EventHook(c.prompt.hook)
 
 	termui.Body.AddRows(
+		termui.NewRow(termui.NewCol(12, 0, c.status)),
 		termui.NewRow(),
 	)
 	termui.Body.Align()
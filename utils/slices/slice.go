@@ -29,3 +29,13 @@ func IndexStrOf(slice []string, target string) int {
 	}
 	return -1
 }
+
+// IndexIntOf for `target` index in `slice`, return -1 if `target` not found
+func IndexIntOf(slice []int, target int) int {
+	for i, item := range slice {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}